@@ -0,0 +1,203 @@
+// Command galign aligns tokens in text files using the format package.
+//
+// It reads the given files or stdin and writes the aligned text to
+// stdout. With -w the files are rewritten in place, with -check
+// the names of files that are not aligned are printed and the exit
+// code is 1 if there are any. With -realign existing padding is
+// collapsed first, so padding left over from longer keys is removed.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/creichlin/gutil/format"
+	gio "github.com/creichlin/gutil/io"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+type config struct {
+	aligner func(string) string
+	write   bool
+	check   bool
+	files   []string
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := parseArgs(args, stderr)
+	if err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(stderr, err) // nolint: errcheck
+		}
+		return 2
+	}
+
+	if len(cfg.files) == 0 {
+		if cfg.write {
+			fmt.Fprintln(stderr, "cannot use -w with stdin") // nolint: errcheck
+			return 2
+		}
+		content, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, err) // nolint: errcheck
+			return 2
+		}
+		return cfg.process("<stdin>", string(content), stdout)
+	}
+
+	exitCode := 0
+	for _, file := range cfg.files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(stderr, err) // nolint: errcheck
+			exitCode = 2
+			continue
+		}
+		code := cfg.processFile(file, string(content), stdout, stderr)
+		if code > exitCode {
+			exitCode = code
+		}
+	}
+	return exitCode
+}
+
+func (cfg *config) process(name, content string, stdout io.Writer) int {
+	aligned := cfg.aligner(content)
+	if cfg.check {
+		if aligned != content {
+			fmt.Fprintln(stdout, name) // nolint: errcheck
+			return 1
+		}
+		return 0
+	}
+	io.WriteString(stdout, aligned) // nolint: errcheck
+	return 0
+}
+
+func (cfg *config) processFile(file, content string, stdout, stderr io.Writer) int {
+	if !cfg.write {
+		return cfg.process(file, content, stdout)
+	}
+
+	aligned := cfg.aligner(content)
+	if aligned == content {
+		return 0
+	}
+
+	info, err := os.Stat(file)
+	if err == nil {
+		err = gio.WriteFileAtomic(file, []byte(aligned), info.Mode().Perm())
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err) // nolint: errcheck
+		return 2
+	}
+	return 0
+}
+
+func parseArgs(args []string, stderr io.Writer) (*config, error) {
+	flags := flag.NewFlagSet("galign", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: galign [flags] [files...]") // nolint: errcheck
+		flags.PrintDefaults()
+	}
+
+	token := flags.String("token", ":", "align on this token")
+	expression := flags.String("regexp", "", "align on the first match of this regular expression instead of a token")
+	mode := flags.String("mode", "all", "blocks to align: all lines, indentation blocks or nested blocks (all|blocks|nested)")
+	blank := flags.String("blank", "indentation", "how blank lines affect blocks (indentation|breaks|continues)")
+	missingBreaks := flags.Bool("missing-breaks", false, "lines without token end blocks")
+	tabWidth := flags.Int("tab-width", 4, "distance of tab stops")
+	padding := flags.String("pad", "before", "where spaces are inserted (before|after|both)")
+	right := flags.Bool("right", false, "align the end of the tokens instead of their start")
+	write := flags.Bool("w", false, "rewrite files in place")
+	check := flags.Bool("check", false, "list files that are not aligned and exit with 1 if there are any")
+	realign := flags.Int("realign", -1, "collapse existing padding to this many spaces before aligning, so stale padding is removed (disabled if negative)")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	matcher := format.Token(*token)
+	if *expression != "" {
+		re, err := regexp.Compile(*expression)
+		if err != nil {
+			return nil, err
+		}
+		matcher = format.Regexp(re)
+	}
+
+	options := format.BlockOptions{
+		TabWidth:           *tabWidth,
+		MissingTokenBreaks: *missingBreaks,
+	}
+
+	switch *padding {
+	case "before":
+		options.Align.Padding = format.PadBefore
+	case "after":
+		options.Align.Padding = format.PadAfter
+	case "both":
+		options.Align.Padding = format.PadBoth
+	default:
+		return nil, fmt.Errorf("invalid -pad %v", *padding)
+	}
+	options.Align.RightAlignToken = *right
+	options.Align.TabWidth = *tabWidth
+
+	switch *blank {
+	case "indentation":
+		options.BlankLines = format.BlankIndentation
+	case "breaks":
+		options.BlankLines = format.BlankBreaks
+	case "continues":
+		options.BlankLines = format.BlankContinues
+	default:
+		return nil, fmt.Errorf("invalid -blank %v", *blank)
+	}
+
+	cfg := &config{
+		write: *write,
+		check: *check,
+		files: flags.Args(),
+	}
+
+	switch *mode {
+	case "all":
+		cfg.aligner = func(input string) string {
+			return format.AlignMatch(input, matcher, options.Align)
+		}
+	case "blocks", "nested":
+		options.Nested = *mode == "nested"
+		cfg.aligner = func(input string) string {
+			return format.AlignBlocks(input, matcher, options)
+		}
+	default:
+		return nil, fmt.Errorf("invalid -mode %v", *mode)
+	}
+
+	if *realign >= 0 {
+		align := cfg.aligner
+		cfg.aligner = func(input string) string {
+			lines := strings.Split(input, "\n")
+			lines = format.UnalignMatchList(lines, matcher, options.Align, *realign)
+			return align(strings.Join(lines, "\n"))
+		}
+	}
+
+	if cfg.write && cfg.check {
+		return nil, errors.New("-w and -check cannot be combined")
+	}
+
+	return cfg, nil
+}
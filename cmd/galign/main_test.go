@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStdin(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	code := run([]string{"-mode", "blocks"}, strings.NewReader("a: 1\nbbb: 2\n  c: 3"), stdout, ioutil.Discard)
+	if code != 0 {
+		t.Errorf("exit code should be 0 but is %v", code)
+	}
+	if stdout.String() != "a  : 1\nbbb: 2\n  c: 3" {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestTabWidth(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	code := run([]string{"-tab-width", "8"}, strings.NewReader("\tx\ta: 1\n\tyyyyyyyy: 2"), stdout, ioutil.Discard)
+	if code != 0 {
+		t.Errorf("exit code should be 0 but is %v", code)
+	}
+	if stdout.String() != "\tx\ta: 1\n\tyyyyyyyy : 2" {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestRealign(t *testing.T) {
+	stale := "a       = 1\nbbb     = 2\n"
+
+	stdout := &bytes.Buffer{}
+	code := run([]string{"-token", "=", "-check"}, strings.NewReader(stale), stdout, ioutil.Discard)
+	if code != 0 {
+		t.Errorf("check without -realign should accept stale padding but exit code was %v", code)
+	}
+
+	code = run([]string{"-token", "=", "-check", "-realign", "1"}, strings.NewReader(stale), stdout, ioutil.Discard)
+	if code != 1 {
+		t.Errorf("check with -realign should report stale padding but exit code was %v", code)
+	}
+
+	stdout.Reset()
+	code = run([]string{"-token", "=", "-realign", "1"}, strings.NewReader(stale), stdout, ioutil.Discard)
+	if code != 0 {
+		t.Errorf("exit code should be 0 but is %v", code)
+	}
+	if stdout.String() != "a   = 1\nbbb = 2\n" {
+		t.Errorf("stale padding should be removed but output is %q", stdout.String())
+	}
+}
+
+func TestCheckAndWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "galign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	aligned := filepath.Join(dir, "aligned.txt")
+	unaligned := filepath.Join(dir, "unaligned.txt")
+	ioutil.WriteFile(aligned, []byte("a   = 1\nbbb = 2\n"), 0600) // nolint: errcheck
+	ioutil.WriteFile(unaligned, []byte("a = 1\nbbb = 2\n"), 0600) // nolint: errcheck
+
+	stdout := &bytes.Buffer{}
+	code := run([]string{"-token", "=", "-check", aligned, unaligned}, nil, stdout, ioutil.Discard)
+	if code != 1 {
+		t.Errorf("check should fail with 1 but was %v", code)
+	}
+	if stdout.String() != unaligned+"\n" {
+		t.Errorf("check should list unaligned file but printed %q", stdout.String())
+	}
+
+	code = run([]string{"-token", "=", "-w", aligned, unaligned}, nil, ioutil.Discard, ioutil.Discard)
+	if code != 0 {
+		t.Errorf("write should succeed but exit code was %v", code)
+	}
+	content, _ := ioutil.ReadFile(unaligned)
+	if string(content) != "a   = 1\nbbb = 2\n" {
+		t.Errorf("file should be aligned but is %q", content)
+	}
+	info, _ := os.Stat(unaligned)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("file permissions should be kept but are %v", info.Mode().Perm())
+	}
+
+	code = run([]string{"-token", "=", "-check", aligned, unaligned}, nil, ioutil.Discard, ioutil.Discard)
+	if code != 0 {
+		t.Errorf("check should pass after write but exit code was %v", code)
+	}
+}
+
+func TestInvalidArgs(t *testing.T) {
+	for _, args := range [][]string{
+		{"-mode", "foo"},
+		{"-regexp", "("},
+		{"-w", "-check", "x"},
+		{"-w"},
+	} {
+		if code := run(args, strings.NewReader(""), ioutil.Discard, ioutil.Discard); code != 2 {
+			t.Errorf("%v should exit with 2 but was %v", args, code)
+		}
+	}
+}
@@ -103,6 +103,11 @@ func (ec *ErrorCollector) ThisOrNil() error {
 	return nil
 }
 
+// All returns a copy of the list of collected errors
+func (ec *ErrorCollector) All() []error {
+	return append([]error{}, ec.errors...)
+}
+
 func (ec *ErrorCollector) StringList() []string {
 	errs := []string{}
 	for _, err := range ec.errors {
@@ -2,45 +2,33 @@ package format
 
 import "strings"
 
+// defaultTabWidth is the distance of tab stops if not configured
+const defaultTabWidth = 4
+
 // AlignIndentedBlocks takes a multiline string as input.
 // This input is partitioned into blocks where all lines in
 // a row having the same indentation belong to the same block.
 // Then for each block the Align function is applied.
+// See AlignBlocks for more control over the blocks.
 func AlignIndentedBlocks(input, token string) string {
-	lines := strings.Split(input, "\n")
-	currentIndentation := countIndentation(lines[0])
-	lastIndex := 0
-	indentedList := []string{}
-
-	for index, line := range lines {
-		indentation := countIndentation(line)
-		if currentIndentation != indentation {
-			indentedList = append(indentedList, AlignList(lines[lastIndex:index], token)...)
-			currentIndentation = indentation
-			lastIndex = index
-		}
-	}
-
-	indentedList = append(indentedList, AlignList(lines[lastIndex:], token)...)
-
-	return strings.Join(indentedList, "\n")
+	return AlignBlocks(input, Token(token), BlockOptions{})
 }
 
-func countIndentation(line string) int {
+// countIndentation returns the column of the first non
+// whitespace character and false, or for lines containing
+// only whitespace, the width of it and true.
+func countIndentation(line string, tabWidth int) (int, bool) {
 	count := 0
 	for _, char := range line {
 		if char == ' ' {
 			count++
 		} else if char == '\t' {
-			count += 4
+			count += tabWidth - count%tabWidth
 		} else {
-			return count
+			return count, false
 		}
 	}
-	// this means there is an empty line
-	// we would probably better mark that with
-	// a second return param or a negative value
-	return count
+	return count, true
 }
 
 // Align will take a multi-line string as input.
@@ -53,26 +41,100 @@ func Align(input, token string) string {
 
 // AlignList works like Align, except that input and output
 // are lists of strings.
+// Positions are measured in terminal columns, so wide
+// characters count twice, combining marks not at all and
+// tabs advance to the next tab stop.
 func AlignList(lines []string, token string) []string {
-	max := 0
+	return AlignMatchList(lines, Token(token), AlignOptions{})
+}
+
+// alignFrom aligns the first match in each line that is at
+// or after the byte offset given in cursors. It returns the
+// modified lines and for each line the offset after the aligned
+// match, or the unchanged cursor if the line has no match.
+func alignFrom(lines []string, cursors []int, matcher Matcher, options AlignOptions) ([]string, []int) {
+	tabWidth := options.TabWidth
+	if tabWidth <= 0 {
+		tabWidth = defaultTabWidth
+	}
+	starts := make([]int, len(lines))
+	ends := make([]int, len(lines))
+	maxPrefix, maxToken, maxEnd := 0, 0, 0
 
-	for _, line := range lines {
-		pos := strings.Index(line, token)
-		if pos > max {
-			max = pos
+	for index, line := range lines {
+		starts[index] = -1
+		start, end := matcher(line[cursors[index]:])
+		if start == -1 {
+			continue
 		}
+		starts[index] = cursors[index] + start
+		ends[index] = cursors[index] + end
+
+		prefix := columnWidth(line[:starts[index]], tabWidth)
+		token := columnWidth(line[:ends[index]], tabWidth) - prefix
+		maxPrefix = maxInt(maxPrefix, prefix)
+		maxToken = maxInt(maxToken, token)
+		maxEnd = maxInt(maxEnd, prefix+token)
 	}
 
 	modLines := []string{}
-	for _, line := range lines {
-		pos := strings.Index(line, token)
-		if pos != -1 {
-			diff := max - pos
-			modLines = append(modLines, line[:pos]+strings.Repeat(" ", diff)+line[pos:])
-		} else {
+	modCursors := []int{}
+	for index, line := range lines {
+		start, end := starts[index], ends[index]
+		if start == -1 {
 			modLines = append(modLines, line)
+			modCursors = append(modCursors, cursors[index])
+			continue
 		}
+
+		prefix := columnWidth(line[:start], tabWidth)
+		token := columnWidth(line[:end], tabWidth) - prefix
+		before, after := 0, 0
+
+		switch {
+		case options.Padding == PadAfter:
+			after = maxEnd - prefix - token
+		case options.Padding == PadBoth && options.RightAlignToken:
+			before = maxPrefix + maxToken - prefix - token
+		case options.Padding == PadBoth:
+			before = maxPrefix - prefix
+			after = maxToken - token
+		case options.RightAlignToken:
+			before = maxEnd - prefix - token
+		default:
+			before = maxPrefix - prefix
+		}
+
+		if end == len(line) {
+			// no trailing whitespace
+			after = 0
+		}
+
+		modLines = append(modLines, line[:start]+strings.Repeat(" ", before)+
+			line[start:end]+strings.Repeat(" ", after)+line[end:])
+		modCursors = append(modCursors, end+before+after)
+	}
+
+	return modLines, modCursors
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
 	}
+	return b
+}
 
-	return modLines
+// columnWidth returns the terminal column at which text
+// following the given line prefix starts
+func columnWidth(prefix string, tabWidth int) int {
+	width := 0
+	for _, char := range prefix {
+		if char == '\t' {
+			width += tabWidth - width%tabWidth
+		} else {
+			width += RuneWidth(char)
+		}
+	}
+	return width
 }
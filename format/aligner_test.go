@@ -2,6 +2,7 @@ package format
 
 import (
 	"github.com/creichlin/gutil/testin"
+	"regexp"
 	"testing"
 )
 
@@ -18,3 +19,110 @@ func TestAlign(t *testing.T) {
 		return ""
 	})
 }
+
+func TestAlignColumns(t *testing.T) {
+	testin.RunMapTests(t, "testcases/columns", func(source, operation string, t *testing.T) string {
+		if operation == "columns" {
+			return AlignColumns(source, "|")
+		}
+		if operation == "sequence" {
+			return AlignSequence(source, "=", "#")
+		}
+		t.Fatalf("Wrong operation %v", operation)
+		return ""
+	})
+}
+
+func TestAlignColumnsEmptyToken(t *testing.T) {
+	input := "a|b\nccc|d"
+	if actual := AlignColumns(input, ""); actual != input {
+		t.Errorf("Empty token should not change the input but got %q", actual)
+	}
+	if actual := AlignColumnsWith(input, "", RightAligned); actual != input {
+		t.Errorf("Empty token should not change the input but got %q", actual)
+	}
+}
+
+func TestAlignMatch(t *testing.T) {
+	any := AnyToken("=", ":=", "+=")
+	cases := map[string]struct {
+		matcher Matcher
+		options AlignOptions
+	}{
+		"any":            {any, AlignOptions{}},
+		"any right":      {any, AlignOptions{RightAlignToken: true}},
+		"any after":      {any, AlignOptions{Padding: PadAfter}},
+		"any both":       {any, AlignOptions{Padding: PadBoth}},
+		"any both right": {any, AlignOptions{Padding: PadBoth, RightAlignToken: true}},
+		"regexp":         {Regexp(regexp.MustCompile(`\s*:=?\s*`)), AlignOptions{}},
+	}
+
+	testin.RunMapTests(t, "testcases/matcher", func(source, operation string, t *testing.T) string {
+		c, found := cases[operation]
+		if !found {
+			t.Fatalf("Wrong operation %v", operation)
+		}
+		return AlignMatch(source, c.matcher, c.options)
+	})
+}
+
+func TestAlignCode(t *testing.T) {
+	yaml := Syntax{Quotes: `"'`, Escape: '\\', LineComments: []string{"#"}}
+	golang := Syntax{Quotes: "\"'`", Escape: '\\', LineComments: []string{"//"}}
+
+	testin.RunMapTests(t, "testcases/syntax", func(source, operation string, t *testing.T) string {
+		if operation == "yaml" {
+			return AlignCode(source, ":", yaml)
+		}
+		if operation == "go" {
+			return AlignMatch(source, golang.Skip(AnyToken("=", "+=")), AlignOptions{RightAlignToken: true})
+		}
+		t.Fatalf("Wrong operation %v", operation)
+		return ""
+	})
+}
+
+func TestAlignBlocks(t *testing.T) {
+	cases := map[string]BlockOptions{
+		"indented block aligned": {},
+		"blank breaks":           {BlankLines: BlankBreaks},
+		"blank continues":        {BlankLines: BlankContinues},
+		"token breaks":           {MissingTokenBreaks: true},
+		"nested":                 {Nested: true, BlankLines: BlankContinues},
+		"tab width 8":            {TabWidth: 8},
+	}
+
+	testin.RunMapTests(t, "testcases/blocks", func(source, operation string, t *testing.T) string {
+		options, found := cases[operation]
+		if !found {
+			t.Fatalf("Wrong operation %v", operation)
+		}
+		return AlignBlocks(source, Token(":"), options)
+	})
+}
+
+func TestAlignBlocksTabWidth(t *testing.T) {
+	input := "\tx\ta: 1\n\tyyyyyyyy: 2"
+	actual := AlignBlocks(input, Token(":"), BlockOptions{TabWidth: 8})
+	expected := "\tx\ta: 1\n\tyyyyyyyy : 2"
+	if actual != expected {
+		t.Errorf("Aligned with tab width 8 should be %q but is %q", expected, actual)
+	}
+}
+
+func TestAlignValues(t *testing.T) {
+	testin.RunMapTests(t, "testcases/values", func(source, operation string, t *testing.T) string {
+		switch operation {
+		case "right":
+			return AlignValues(source, ":", RightAligned)
+		case "center":
+			return AlignValues(source, ":", Centered)
+		case "decimal":
+			return AlignValues(source, ":", DecimalAligned)
+		case "columns":
+			return AlignColumnsWith(source, "|", RightAligned, DecimalAligned)
+		}
+		t.Fatalf("Wrong operation %v", operation)
+		return ""
+	})
+}
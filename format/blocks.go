@@ -0,0 +1,158 @@
+package format
+
+import "strings"
+
+// BlankLineMode defines how blank lines affect blocks
+type BlankLineMode int
+
+// Blank line modes
+const (
+	// BlankIndentation treats blank lines like other lines,
+	// their indentation is the whitespace they contain.
+	BlankIndentation BlankLineMode = iota
+	// BlankBreaks ends all blocks at blank lines
+	BlankBreaks
+	// BlankContinues ignores blank lines, the blocks
+	// before and after are joined if they match
+	BlankContinues
+)
+
+// BlockOptions configure how AlignBlocks partitions
+// lines into blocks
+type BlockOptions struct {
+	// TabWidth is the distance of tab stops when counting
+	// indentation and, if Align.TabWidth is 0, when aligning,
+	// 4 if 0
+	TabWidth int
+	// BlankLines defines how blank lines are handled
+	BlankLines BlankLineMode
+	// MissingTokenBreaks ends the block at lines without token
+	MissingTokenBreaks bool
+	// Nested keeps a block open while deeper indented lines
+	// follow, so all children of a parent are aligned
+	// together even if some of them have children themselves.
+	Nested bool
+	// Align configures the alignment inside each block
+	Align AlignOptions
+}
+
+type block struct {
+	indentation int
+	lines       []int
+}
+
+// AlignBlocks partitions the input into blocks of lines with
+// the same indentation and aligns the first match of the matcher
+// in each block. With default options it works like
+// AlignIndentedBlocks.
+func AlignBlocks(input string, matcher Matcher, options BlockOptions) string {
+	lines := strings.Split(input, "\n")
+	return strings.Join(AlignBlocksList(lines, matcher, options), "\n")
+}
+
+// AlignBlocksList works like AlignBlocks, except that input and
+// output are lists of strings.
+func AlignBlocksList(lines []string, matcher Matcher, options BlockOptions) []string {
+	p := newPartitioner(matcher, options)
+	for index, line := range lines {
+		p.add(index, line)
+	}
+	return p.align(lines)
+}
+
+// partitioner assigns lines to blocks one by one
+type partitioner struct {
+	matcher  Matcher
+	options  BlockOptions
+	tabWidth int
+	blocks   []*block
+	open     []*block
+}
+
+func newPartitioner(matcher Matcher, options BlockOptions) *partitioner {
+	tabWidth := options.TabWidth
+	if tabWidth <= 0 {
+		tabWidth = defaultTabWidth
+	}
+	if options.Align.TabWidth <= 0 {
+		options.Align.TabWidth = tabWidth
+	}
+	return &partitioner{
+		matcher:  matcher,
+		options:  options,
+		tabWidth: tabWidth,
+	}
+}
+
+// closeFrom closes open blocks that are at least as deep as indentation
+func (p *partitioner) closeFrom(indentation int) {
+	for len(p.open) > 0 && p.open[len(p.open)-1].indentation >= indentation {
+		p.open = p.open[:len(p.open)-1]
+	}
+}
+
+// add assigns the line with the given index to a block,
+// lines must be added in order
+func (p *partitioner) add(index int, line string) {
+	indentation, blank := countIndentation(line, p.tabWidth)
+
+	if blank && p.options.BlankLines == BlankBreaks {
+		p.open = nil
+		return
+	}
+	if blank && p.options.BlankLines == BlankContinues {
+		return
+	}
+
+	if p.options.MissingTokenBreaks {
+		if start, _ := p.matcher(line); start == -1 {
+			if p.options.Nested {
+				p.closeFrom(indentation)
+			} else {
+				p.open = nil
+			}
+			return
+		}
+	}
+
+	if p.options.Nested {
+		p.closeFrom(indentation + 1)
+	} else if len(p.open) > 0 && p.open[0].indentation != indentation {
+		p.open = nil
+	}
+
+	if len(p.open) == 0 || p.open[len(p.open)-1].indentation != indentation {
+		current := &block{indentation: indentation}
+		p.blocks = append(p.blocks, current)
+		p.open = append(p.open, current)
+	}
+	current := p.open[len(p.open)-1]
+	current.lines = append(current.lines, index)
+}
+
+// settled reports if all blocks containing lines
+// before index are closed
+func (p *partitioner) settled(index int) bool {
+	for _, open := range p.open {
+		if open.lines[0] < index {
+			return false
+		}
+	}
+	return true
+}
+
+// align aligns the lines of each block, lines must
+// contain all lines added so far
+func (p *partitioner) align(lines []string) []string {
+	modLines := append([]string{}, lines...)
+	for _, current := range p.blocks {
+		blockLines := []string{}
+		for _, index := range current.lines {
+			blockLines = append(blockLines, lines[index])
+		}
+		for i, line := range AlignMatchList(blockLines, p.matcher, p.options.Align) {
+			modLines[current.lines[i]] = line
+		}
+	}
+	return modLines
+}
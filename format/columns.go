@@ -0,0 +1,64 @@
+package format
+
+import "strings"
+
+// AlignColumns works like Align but aligns all occurrences
+// of the token, not only the first. The first occurrences
+// are aligned, then the second ones and so on, like columns
+// of a table.
+func AlignColumns(input, token string) string {
+	lines := strings.Split(input, "\n")
+	return strings.Join(AlignColumnsList(lines, token), "\n")
+}
+
+// AlignColumnsList works like AlignColumns, except that input
+// and output are lists of strings.
+func AlignColumnsList(lines []string, token string) []string {
+	return AlignColumnsWithList(lines, token)
+}
+
+// AlignSequence aligns a sequence of different tokens one after
+// the other. For each token the first occurrence after the previous
+// token is aligned. A line missing one of the tokens still takes
+// part in aligning the following ones. So for lines like
+// "key = value # comment", aligning on "=" and "#" also aligns
+// comments of lines without assignment. Lines starting with a
+// token, like full line comments, are left where they are.
+func AlignSequence(input string, tokens ...string) string {
+	lines := strings.Split(input, "\n")
+	return strings.Join(AlignSequenceList(lines, tokens...), "\n")
+}
+
+// AlignSequenceList works like AlignSequence, except that input
+// and output are lists of strings.
+func AlignSequenceList(lines []string, tokens ...string) []string {
+	lines = append([]string{}, lines...)
+	cursors := make([]int, len(lines))
+	for _, token := range tokens {
+		matcher := Token(token)
+
+		// lines starting with the token, like full line
+		// comments, are not aligned
+		indices := []int{}
+		for index, line := range lines {
+			start, _ := matcher(line[cursors[index]:])
+			if start != -1 && strings.TrimSpace(line[:cursors[index]+start]) == "" {
+				continue
+			}
+			indices = append(indices, index)
+		}
+
+		selected := make([]string, len(indices))
+		selectedCursors := make([]int, len(indices))
+		for i, index := range indices {
+			selected[i] = lines[index]
+			selectedCursors[i] = cursors[index]
+		}
+		selected, selectedCursors = alignFrom(selected, selectedCursors, matcher, AlignOptions{})
+		for i, index := range indices {
+			lines[index] = selected[i]
+			cursors[index] = selectedCursors[i]
+		}
+	}
+	return lines
+}
@@ -0,0 +1,100 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Matcher finds the first token in s and returns it's start
+// and end byte offset or -1, -1 if there is none
+type Matcher func(s string) (int, int)
+
+// Token returns a matcher for a literal token
+func Token(token string) Matcher {
+	return func(s string) (int, int) {
+		pos := strings.Index(s, token)
+		if pos == -1 {
+			return -1, -1
+		}
+		return pos, pos + len(token)
+	}
+}
+
+// AnyToken returns a matcher for the leftmost of the given tokens,
+// if more than one start at the same position the longest wins.
+// So with "=", ":=" and "+=" the whole ":=" is matched.
+func AnyToken(tokens ...string) Matcher {
+	return func(s string) (int, int) {
+		start, end := -1, -1
+		for _, token := range tokens {
+			pos := strings.Index(s, token)
+			if pos == -1 {
+				continue
+			}
+			if start == -1 || pos < start || (pos == start && pos+len(token) > end) {
+				start, end = pos, pos+len(token)
+			}
+		}
+		return start, end
+	}
+}
+
+// Regexp returns a matcher for the leftmost match of the expression.
+// Whitespace at the start and end of the match is not part of the
+// token, so expressions like `\s*:\s*` align the colons and not
+// the whitespace before them.
+func Regexp(expression *regexp.Regexp) Matcher {
+	return func(s string) (int, int) {
+		loc := expression.FindStringIndex(s)
+		if loc == nil {
+			return -1, -1
+		}
+		token := strings.TrimSpace(s[loc[0]:loc[1]])
+		if token == "" {
+			return loc[0], loc[1]
+		}
+		start := loc[0] + strings.Index(s[loc[0]:loc[1]], token)
+		return start, start + len(token)
+	}
+}
+
+// Padding defines where spaces are inserted when aligning
+type Padding int
+
+// Padding modes
+const (
+	// PadBefore inserts spaces before the token so tokens are aligned
+	PadBefore Padding = iota
+	// PadAfter inserts spaces after the token so the text following
+	// the tokens is aligned, tokens stick to the text before them.
+	// Like with PadBoth, no spaces are added after a token at
+	// the end of a line.
+	PadAfter
+	// PadBoth aligns the tokens and the text following them
+	PadBoth
+)
+
+// AlignOptions configure AlignMatch
+type AlignOptions struct {
+	Padding Padding
+	// RightAlignToken aligns the end of the tokens instead of their
+	// start, so "=" lines up with the "=" of ":=" and "+="
+	RightAlignToken bool
+	// TabWidth is the distance of tab stops when measuring
+	// columns, 4 if 0
+	TabWidth int
+}
+
+// AlignMatch works like Align but aligns on the first match
+// of the matcher with configurable padding.
+func AlignMatch(input string, matcher Matcher, options AlignOptions) string {
+	lines := strings.Split(input, "\n")
+	return strings.Join(AlignMatchList(lines, matcher, options), "\n")
+}
+
+// AlignMatchList works like AlignMatch, except that input and
+// output are lists of strings.
+func AlignMatchList(lines []string, matcher Matcher, options AlignOptions) []string {
+	modLines, _ := alignFrom(lines, make([]int, len(lines)), matcher, options)
+	return modLines
+}
@@ -0,0 +1,95 @@
+package format
+
+import "strings"
+
+// Syntax describes quoted strings and comments of a language
+// so tokens inside them can be ignored when aligning
+type Syntax struct {
+	// Quotes are the characters that start and end a string
+	Quotes string
+	// Escape is the character escaping the next one inside
+	// strings, 0 if the language has none
+	Escape rune
+	// LineComments are the prefixes starting a comment
+	// that lasts till the end of the line
+	LineComments []string
+}
+
+// Skip wraps a matcher so that matches starting inside
+// a quoted string or a comment are skipped.
+func (s Syntax) Skip(matcher Matcher) Matcher {
+	return func(line string) (int, int) {
+		code := s.codeMask(line)
+		offset := 0
+
+		for offset <= len(line) {
+			start, end := matcher(line[offset:])
+			if start == -1 {
+				return -1, -1
+			}
+			start += offset
+			end += offset
+			if start == len(line) || code[start] {
+				return start, end
+			}
+			offset = start + 1
+		}
+		return -1, -1
+	}
+}
+
+// AlignCode works like Align but ignores tokens in
+// strings and comments of the given syntax.
+func AlignCode(input, token string, syntax Syntax) string {
+	return AlignMatch(input, syntax.Skip(Token(token)), AlignOptions{})
+}
+
+// codeMask returns for every byte of the line if
+// it's code and not part of a string or comment
+func (s Syntax) codeMask(line string) []bool {
+	code := make([]bool, len(line))
+	var quote rune
+	escaped := false
+
+	for index, char := range line {
+		if quote != 0 {
+			switch {
+			case escaped:
+				escaped = false
+			case s.Escape != 0 && char == s.Escape:
+				escaped = true
+			case char == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		if s.isComment(line[index:]) {
+			break
+		}
+
+		if strings.ContainsRune(s.Quotes, char) {
+			quote = char
+			continue
+		}
+
+		for i := index; i < len(line) && (i == index || !isRuneStart(line[i])); i++ {
+			code[i] = true
+		}
+	}
+
+	return code
+}
+
+func (s Syntax) isComment(rest string) bool {
+	for _, prefix := range s.LineComments {
+		if prefix != "" && strings.HasPrefix(rest, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,253 @@
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TableStyle defines how a table is rendered
+type TableStyle int
+
+// Table styles
+const (
+	// PlainTable pads columns with spaces
+	PlainTable TableStyle = iota
+	// MarkdownTable renders a github flavored markdown table
+	MarkdownTable
+	// CSVTable renders comma separated values, cells are never truncated
+	CSVTable
+)
+
+// Column selects a value of the rows for a table column
+type Column struct {
+	// Key is the map key or struct field name
+	Key string
+	// Header is the column title, if empty Key is used
+	Header string
+	// MaxWidth truncates longer cells if it's bigger than 0
+	MaxWidth int
+}
+
+// Table renders lists of maps or structs
+type Table struct {
+	// Columns defines the order and selection of columns.
+	// If empty, all keys of all rows are used, sorted for
+	// maps and in field order for structs
+	Columns []Column
+	Style   TableStyle
+}
+
+// RenderTable renders the rows as plain table with all columns
+func RenderTable(rows interface{}) (string, error) {
+	return (&Table{}).Render(rows)
+}
+
+// Render renders rows, which must be a slice of maps or
+// structs or pointers to them, as table. Without rows only the
+// header is rendered, if there are no columns either it returns
+// an empty string.
+func (t *Table) Render(rows interface{}) (string, error) {
+	list := reflect.ValueOf(rows)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return "", fmt.Errorf("table rows must be a list but is %T", rows)
+	}
+
+	records := []map[string]interface{}{}
+	keys := []string{}
+	seen := map[string]bool{}
+
+	for i := 0; i < list.Len(); i++ {
+		record, recordKeys, err := tableRecord(list.Index(i))
+		if err != nil {
+			return "", fmt.Errorf("row %v, %v", i, err)
+		}
+		for _, key := range recordKeys {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		records = append(records, record)
+	}
+
+	columns := t.Columns
+	if len(columns) == 0 {
+		if list.Len() > 0 && isMapRow(list.Index(0)) {
+			sort.Strings(keys)
+		}
+		for _, key := range keys {
+			columns = append(columns, Column{Key: key})
+		}
+	}
+
+	header := []string{}
+	for _, column := range columns {
+		if column.Header != "" {
+			header = append(header, column.Header)
+		} else {
+			header = append(header, column.Key)
+		}
+	}
+
+	cells := [][]string{}
+	for _, record := range records {
+		row := []string{}
+		for _, column := range columns {
+			cell := ""
+			if value, found := record[column.Key]; found && value != nil {
+				cell = formatCell(value)
+			}
+			if t.Style != CSVTable {
+				cell = strings.NewReplacer("\n", " ", "\t", " ", cellSeparator, "").Replace(cell)
+				if column.MaxWidth > 0 {
+					cell = Truncate(cell, column.MaxWidth)
+				}
+			}
+			row = append(row, cell)
+		}
+		cells = append(cells, row)
+	}
+
+	if len(columns) == 0 {
+		// an empty list without columns has nothing to show
+		return "", nil
+	}
+
+	switch t.Style {
+	case PlainTable:
+		return renderPlain(header, cells), nil
+	case MarkdownTable:
+		return renderMarkdown(header, cells), nil
+	case CSVTable:
+		return renderCSV(header, cells)
+	}
+	return "", fmt.Errorf("unknown table style %v", t.Style)
+}
+
+// formatCell formats floats without exponent, json numbers
+// are floats and would be printed like 2.154e+07 otherwise
+func formatCell(value interface{}) string {
+	switch number := value.(type) {
+	case float64:
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(number), 'f', -1, 32)
+	}
+	return fmt.Sprint(value)
+}
+
+// cellSeparator separates cells while aligning them, it's
+// removed from the cells and has no width
+const cellSeparator = "\x00"
+
+// alignCells pads the cells of all rows to the width of their
+// column using AlignColumnsList
+func alignCells(rows [][]string) [][]string {
+	lines := []string{}
+	for _, row := range rows {
+		lines = append(lines, strings.Join(row, cellSeparator)+cellSeparator)
+	}
+
+	aligned := [][]string{}
+	for _, line := range AlignColumnsList(lines, cellSeparator) {
+		cells := strings.Split(line, cellSeparator)
+		aligned = append(aligned, cells[:len(cells)-1])
+	}
+	return aligned
+}
+
+func renderPlain(header []string, cells [][]string) string {
+	out := ""
+	for _, row := range alignCells(append([][]string{header}, cells...)) {
+		out += strings.TrimRight(strings.Join(row, "  "), " ") + "\n"
+	}
+	return out
+}
+
+func renderMarkdown(header []string, cells [][]string) string {
+	// the separator row makes all columns at least 3 wide
+	separator := []string{}
+	for range header {
+		separator = append(separator, "---")
+	}
+
+	rows := [][]string{}
+	for _, row := range append([][]string{header, separator}, cells...) {
+		escapedRow := []string{}
+		for _, cell := range row {
+			escapedRow = append(escapedRow, strings.Replace(cell, "|", "\\|", -1))
+		}
+		rows = append(rows, escapedRow)
+	}
+
+	aligned := alignCells(rows)
+	for i, cell := range aligned[1] {
+		aligned[1][i] = strings.Replace(cell, " ", "-", -1)
+	}
+
+	out := ""
+	for _, row := range aligned {
+		out += "| " + strings.Join(row, " | ") + " |\n"
+	}
+	return out
+}
+
+func renderCSV(header []string, cells [][]string) (string, error) {
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+	if err := writer.Write(header); err != nil {
+		return "", err
+	}
+	if err := writer.WriteAll(cells); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func isMapRow(value reflect.Value) bool {
+	for value.Kind() == reflect.Interface || value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	return value.Kind() == reflect.Map
+}
+
+// tableRecord returns the values of a map or struct and it's keys,
+// sorted for maps and in field order for structs
+func tableRecord(value reflect.Value) (map[string]interface{}, []string, error) {
+	for value.Kind() == reflect.Interface || value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+
+	record := map[string]interface{}{}
+	keys := []string{}
+
+	switch value.Kind() {
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			name := fmt.Sprint(key.Interface())
+			record[name] = value.MapIndex(key).Interface()
+			keys = append(keys, name)
+		}
+		sort.Strings(keys)
+
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			record[field.Name] = value.Field(i).Interface()
+			keys = append(keys, field.Name)
+		}
+
+	default:
+		return nil, nil, fmt.Errorf("rows must be maps or structs but got %v", value.Kind())
+	}
+
+	return record, keys, nil
+}
@@ -0,0 +1,96 @@
+package format
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/creichlin/gutil/testin"
+)
+
+func TestTable(t *testing.T) {
+	testin.RunMapTests(t, "testcases/table", func(source, operation string, t *testing.T) string {
+		rows := []map[string]interface{}{}
+		if err := json.Unmarshal([]byte(source), &rows); err != nil {
+			t.Fatal(err)
+		}
+
+		table := &Table{}
+		switch operation {
+		case "plain":
+		case "markdown":
+			table.Style = MarkdownTable
+		case "csv":
+			table.Style = CSVTable
+		case "selected":
+			table.Columns = []Column{
+				{Key: "name", Header: "Name"},
+				{Key: "city", Header: "City", MaxWidth: 12},
+			}
+		default:
+			t.Fatalf("Wrong operation %v", operation)
+		}
+
+		out, err := table.Render(rows)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSuffix(out, "\n")
+	})
+}
+
+func TestTableOfStructs(t *testing.T) {
+	type record struct {
+		Name  string
+		Port  int
+		debug bool
+	}
+
+	out, err := RenderTable([]*record{{"web", 80, true}, {"database", 5432, false}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Name      Port\nweb       80\ndatabase  5432\n"
+	if out != expected {
+		t.Errorf("table should be\n%v\nbut is\n%v", expected, out)
+	}
+}
+
+func TestEmptyTable(t *testing.T) {
+	testCases := []struct {
+		table    Table
+		expected string
+	}{
+		{Table{Style: PlainTable}, ""},
+		{Table{Style: MarkdownTable}, ""},
+		{Table{Style: CSVTable}, ""},
+		{Table{Style: PlainTable, Columns: []Column{{Key: "name"}}}, "name\n"},
+		{Table{Style: MarkdownTable, Columns: []Column{{Key: "name"}}}, "| name |\n| ---- |\n"},
+	}
+
+	for _, testCase := range testCases {
+		out, err := testCase.table.Render([]map[string]interface{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != testCase.expected {
+			t.Errorf("empty table in style %v should be %q but is %q", testCase.table.Style, testCase.expected, out)
+		}
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	testCases := map[string]int{
+		"abc":       3,
+		"Zoë":       3,
+		"Zoe\u0308": 3,
+		"王小明":       6,
+		"a🎉b":       4,
+		"":          0,
+	}
+	for str, width := range testCases {
+		if StringWidth(str) != width {
+			t.Errorf("width of %q should be %v but is %v", str, width, StringWidth(str))
+		}
+	}
+}
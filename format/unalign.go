@@ -0,0 +1,66 @@
+package format
+
+import "strings"
+
+// Unalign replaces the whitespace before the first token of
+// each line by minimum spaces, this undoes the padding Align
+// inserted. If the token is the first thing on a line, the
+// whitespace before it is left as it is because indentation
+// can't be told apart from padding.
+func Unalign(input, token string, minimum int) string {
+	lines := strings.Split(input, "\n")
+	return strings.Join(UnalignMatchList(lines, Token(token), AlignOptions{}, minimum), "\n")
+}
+
+// Realign first unaligns and then aligns the input again, so
+// stale padding from keys that got shorter is removed. Running
+// it on its own output does not change it anymore.
+func Realign(input, token string, minimum int) string {
+	lines := strings.Split(input, "\n")
+	return strings.Join(RealignMatchList(lines, Token(token), AlignOptions{}, minimum), "\n")
+}
+
+// RealignMatchList works like Realign for a matcher and
+// alignment options, input and output are lists of strings.
+func RealignMatchList(lines []string, matcher Matcher, options AlignOptions, minimum int) []string {
+	return AlignMatchList(UnalignMatchList(lines, matcher, options, minimum), matcher, options)
+}
+
+// UnalignMatchList works like Unalign for a matcher. The options
+// define where padding is expected. With PadAfter and PadBoth
+// whitespace after the match is collapsed too, or removed if
+// nothing follows it.
+func UnalignMatchList(lines []string, matcher Matcher, options AlignOptions, minimum int) []string {
+	padding := strings.Repeat(" ", minimum)
+	modLines := []string{}
+
+	for _, line := range lines {
+		start, end := matcher(line)
+		if start == -1 {
+			modLines = append(modLines, line)
+			continue
+		}
+
+		before := line[:start]
+		if options.Padding != PadAfter {
+			trimmed := strings.TrimRight(before, " \t")
+			if trimmed != "" {
+				before = trimmed + padding
+			}
+		}
+
+		after := line[end:]
+		if options.Padding != PadBefore {
+			trimmed := strings.TrimLeft(after, " \t")
+			if trimmed != "" {
+				after = padding + trimmed
+			} else {
+				after = ""
+			}
+		}
+
+		modLines = append(modLines, before+line[start:end]+after)
+	}
+
+	return modLines
+}
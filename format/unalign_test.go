@@ -0,0 +1,73 @@
+package format
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnalign(t *testing.T) {
+	actual := Unalign("a     = 1\nbb\t= 2\n    = 3\nnone", "=", 1)
+	expected := "a = 1\nbb = 2\n    = 3\nnone"
+	if actual != expected {
+		t.Errorf("unalign should be %q but is %q", expected, actual)
+	}
+}
+
+func TestRealignShrinks(t *testing.T) {
+	aligned := Align("longkey = 1\nb = 2", "=")
+	edited := strings.Replace(aligned, "longkey", "key", 1)
+
+	actual := Realign(edited, "=", 1)
+	expected := "key = 1\nb   = 2"
+	if actual != expected {
+		t.Errorf("realign should be %q but is %q", expected, actual)
+	}
+}
+
+// randomLines creates lines of words, spaces and tokens
+// where the token is never the first thing on a line
+func randomLines(random *rand.Rand) []string {
+	parts := []string{"a", "key", "Größe", "名前", " ", "  ", "\t", "=", ":=", "x y"}
+	lines := []string{}
+	for i := random.Intn(6); i >= 0; i-- {
+		line := strings.Repeat(" ", random.Intn(3)) + "k"
+		for j := random.Intn(6); j >= 0; j-- {
+			line += parts[random.Intn(len(parts))]
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestRealignProperties(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	optionsList := []AlignOptions{
+		{},
+		{RightAlignToken: true},
+		{Padding: PadAfter},
+		{Padding: PadBoth},
+	}
+	matcher := AnyToken("=", ":=")
+
+	for i := 0; i < 2000; i++ {
+		lines := randomLines(random)
+		options := optionsList[random.Intn(len(optionsList))]
+		minimum := random.Intn(3)
+
+		realigned := RealignMatchList(lines, matcher, options, minimum)
+
+		// idempotent
+		again := RealignMatchList(realigned, matcher, options, minimum)
+		if strings.Join(again, "\n") != strings.Join(realigned, "\n") {
+			t.Fatalf("realign is not idempotent for %q with %+v, %v:\n%q\n%q", lines, options, minimum, realigned, again)
+		}
+
+		// aligned text realigns like the fresh text
+		aligned := AlignMatchList(lines, matcher, options)
+		fromAligned := RealignMatchList(aligned, matcher, options, minimum)
+		if strings.Join(fromAligned, "\n") != strings.Join(realigned, "\n") {
+			t.Fatalf("realign of aligned text differs for %q with %+v, %v:\n%q\n%q", lines, options, minimum, realigned, fromAligned)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package format
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ColumnAlignment defines how text is aligned inside a column
+type ColumnAlignment int
+
+// Column alignments
+const (
+	// LeftAligned leaves the text as it is
+	LeftAligned ColumnAlignment = iota
+	// RightAligned aligns the end of the text
+	RightAligned
+	// Centered centers the text in the column
+	Centered
+	// DecimalAligned aligns the decimal points of numbers, numbers
+	// without a point are aligned as if it was at their end.
+	// Text that is not a number is left as it is.
+	DecimalAligned
+)
+
+// AlignValues works like Align and then aligns the text
+// following the token with the given alignment.
+func AlignValues(input, token string, alignment ColumnAlignment) string {
+	lines := strings.Split(input, "\n")
+	return strings.Join(AlignValuesList(lines, token, alignment), "\n")
+}
+
+// AlignValuesList works like AlignValues, except that input
+// and output are lists of strings.
+func AlignValuesList(lines []string, token string, alignment ColumnAlignment) []string {
+	cursors := make([]int, len(lines))
+	modLines, modCursors := alignFrom(lines, cursors, Token(token), AlignOptions{})
+	return alignValue(modLines, cursors, modCursors, nil, alignment)
+}
+
+// AlignColumnsWith works like AlignColumns and then aligns the
+// text following the n-th token with alignments[n-1]. Columns
+// without alignment are left aligned.
+func AlignColumnsWith(input, token string, alignments ...ColumnAlignment) string {
+	lines := strings.Split(input, "\n")
+	return strings.Join(AlignColumnsWithList(lines, token, alignments...), "\n")
+}
+
+// AlignColumnsWithList works like AlignColumnsWith, except that
+// input and output are lists of strings.
+func AlignColumnsWithList(lines []string, token string, alignments ...ColumnAlignment) []string {
+	if token == "" {
+		// like Align, an empty token aligns nothing
+		return lines
+	}
+	cursors := make([]int, len(lines))
+	matcher := Token(token)
+
+	for column := 0; ; column++ {
+		found := false
+		for index, line := range lines {
+			if strings.Contains(line[cursors[index]:], token) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return lines
+		}
+
+		var modCursors []int
+		lines, modCursors = alignFrom(lines, cursors, matcher, AlignOptions{})
+		if column < len(alignments) {
+			lines = alignValue(lines, cursors, modCursors, matcher, alignments[column])
+		}
+		cursors = modCursors
+	}
+}
+
+// alignValue aligns the text following the tokens that were just
+// aligned, lines where the cursor did not move had no token. The
+// text ends at the next match of next or, if next is nil or does
+// not match, at the end of the line. Spaces are inserted before
+// the text, so following tokens have to be aligned afterwards.
+func alignValue(lines []string, before, after []int, next Matcher, alignment ColumnAlignment) []string {
+	if alignment == LeftAligned {
+		return lines
+	}
+
+	type value struct {
+		start   int // byte offset
+		column  int
+		width   int
+		integer int // width till the decimal point
+	}
+	values := make([]*value, len(lines))
+	maxColumn, maxWidth, maxInteger := 0, 0, 0
+
+	for index, line := range lines {
+		if before[index] == after[index] {
+			continue
+		}
+		rest := line[after[index]:]
+		if next != nil {
+			if start, _ := next(rest); start != -1 {
+				rest = rest[:start]
+			}
+		}
+		content := strings.TrimSpace(rest)
+		if content == "" {
+			continue
+		}
+		if alignment == DecimalAligned {
+			if _, err := strconv.ParseFloat(content, 64); err != nil {
+				continue
+			}
+		}
+
+		v := &value{start: after[index] + strings.Index(rest, content)}
+		v.column = columnWidth(line[:v.start], defaultTabWidth)
+		v.width = StringWidth(content)
+		v.integer = v.width
+		if point := strings.Index(content, "."); point != -1 {
+			v.integer = StringWidth(content[:point])
+		}
+		values[index] = v
+
+		maxColumn = maxInt(maxColumn, v.column)
+		maxWidth = maxInt(maxWidth, v.width)
+		maxInteger = maxInt(maxInteger, v.integer)
+	}
+
+	modLines := []string{}
+	for index, line := range lines {
+		v := values[index]
+		if v == nil {
+			modLines = append(modLines, line)
+			continue
+		}
+
+		target := maxColumn
+		switch alignment {
+		case RightAligned:
+			target += maxWidth - v.width
+		case Centered:
+			target += (maxWidth - v.width) / 2
+		case DecimalAligned:
+			target += maxInteger - v.integer
+		}
+
+		padding := strings.Repeat(" ", target-v.column)
+		modLines = append(modLines, line[:v.start]+padding+line[v.start:])
+	}
+	return modLines
+}
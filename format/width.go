@@ -0,0 +1,82 @@
+package format
+
+import "unicode"
+
+// wideRanges are the east asian wide and fullwidth ranges
+// as well as emoji which terminals render in two columns
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},   // hangul jamo
+	{0x2E80, 0x303E},   // cjk radicals, kangxi, cjk symbols
+	{0x3041, 0x33FF},   // hiragana, katakana, bopomofo, cjk compatibility
+	{0x3400, 0x4DBF},   // cjk extension a
+	{0x4E00, 0x9FFF},   // cjk unified ideographs
+	{0xA000, 0xA4CF},   // yi
+	{0xAC00, 0xD7A3},   // hangul syllables
+	{0xF900, 0xFAFF},   // cjk compatibility ideographs
+	{0xFE30, 0xFE4F},   // cjk compatibility forms
+	{0xFF00, 0xFF60},   // fullwidth forms
+	{0xFFE0, 0xFFE6},   // fullwidth signs
+	{0x1F300, 0x1F64F}, // pictographs and emoticons
+	{0x1F680, 0x1F6FF}, // transport and map symbols
+	{0x1F900, 0x1F9FF}, // supplemental symbols and pictographs
+	{0x1FA70, 0x1FAFF}, // symbols and pictographs extended
+	{0x20000, 0x2FFFD}, // cjk extension b and later
+	{0x30000, 0x3FFFD},
+}
+
+// RuneWidth returns the number of terminal columns
+// the rune occupies, 0 for combining marks and control
+// characters, 2 for wide characters and 1 otherwise.
+func RuneWidth(r rune) int {
+	if r < 0x20 || (r >= 0x7F && r < 0xA0) {
+		return 0
+	}
+	if r < 0x300 {
+		return 1
+	}
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	for _, wide := range wideRanges {
+		if r < wide[0] {
+			return 1
+		}
+		if r <= wide[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// StringWidth returns the number of terminal columns
+// the string occupies, see RuneWidth
+func StringWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += RuneWidth(r)
+	}
+	return width
+}
+
+// Truncate shortens s to at most width columns. If it has
+// to cut, the last column is replaced by an ellipsis.
+func Truncate(s string, width int) string {
+	if StringWidth(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+
+	result := []rune{}
+	used := 0
+	for _, r := range s {
+		runeWidth := RuneWidth(r)
+		if used+runeWidth > width-1 {
+			break
+		}
+		result = append(result, r)
+		used += runeWidth
+	}
+	return string(result) + "…"
+}
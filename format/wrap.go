@@ -0,0 +1,147 @@
+package format
+
+import "strings"
+
+// Wrap reflows each paragraph of the text so lines are at most
+// width terminal columns wide. Paragraphs are separated by blank
+// lines, the indentation of a paragraph's first line is used for
+// all it's lines. Words longer than the width are not broken.
+func Wrap(text string, width int) string {
+	paragraphs := []string{}
+	for _, paragraph := range splitParagraphs(text) {
+		if len(paragraph) == 0 {
+			paragraphs = append(paragraphs, "")
+			continue
+		}
+		prefix := leadingWhitespace(paragraph[0])
+		words := strings.Fields(strings.Join(paragraph, " "))
+		lines := wrapWords(words, width-columnWidth(prefix, defaultTabWidth), width-columnWidth(prefix, defaultTabWidth))
+		paragraphs = append(paragraphs, Indent(strings.Join(lines, "\n"), prefix))
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+// WrapIndented wraps the text as one paragraph so lines are at most
+// width columns wide including their prefix. The first line is
+// prefixed with first, all others with rest. If first is a flag name
+// and rest spaces of the same width, this is the hanging indentation
+// of help texts.
+func WrapIndented(text string, width int, first, rest string) string {
+	words := strings.Fields(text)
+	lines := wrapWords(words, width-columnWidth(first, defaultTabWidth), width-columnWidth(rest, defaultTabWidth))
+	for i := range lines {
+		if i == 0 {
+			lines[i] = first + lines[i]
+		} else {
+			lines[i] = rest + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Indent adds the prefix to all lines that are not blank
+func Indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if _, blank := countIndentation(line, defaultTabWidth); !blank {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Dedent removes the indentation all non blank lines have in common.
+// Indentation is measured in columns, tabs which reach into the
+// removed columns are replaced by spaces. Blank lines become empty.
+func Dedent(text string) string {
+	lines := strings.Split(text, "\n")
+	common := -1
+	for _, line := range lines {
+		indentation, blank := countIndentation(line, defaultTabWidth)
+		if !blank && (common == -1 || indentation < common) {
+			common = indentation
+		}
+	}
+
+	for i, line := range lines {
+		if _, blank := countIndentation(line, defaultTabWidth); blank {
+			lines[i] = ""
+		} else {
+			lines[i] = removeIndentation(line, common)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// removeIndentation removes columns of leading whitespace
+func removeIndentation(line string, columns int) string {
+	column := 0
+	for index, char := range line {
+		if column >= columns {
+			return line[index:]
+		}
+		if char == '\t' {
+			column += defaultTabWidth - column%defaultTabWidth
+			if column > columns {
+				return strings.Repeat(" ", column-columns) + line[index+1:]
+			}
+		} else {
+			column++
+		}
+	}
+	return ""
+}
+
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// splitParagraphs returns the lines of each paragraph, blank
+// lines are returned as empty paragraphs to keep them
+func splitParagraphs(text string) [][]string {
+	paragraphs := [][]string{}
+	current := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if _, blank := countIndentation(line, defaultTabWidth); blank {
+			if len(current) > 0 {
+				paragraphs = append(paragraphs, current)
+				current = []string{}
+			}
+			paragraphs = append(paragraphs, nil)
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		paragraphs = append(paragraphs, current)
+	}
+	return paragraphs
+}
+
+// wrapWords fills words into lines, the first line may
+// have a different width than the others
+func wrapWords(words []string, firstWidth, width int) []string {
+	lines := []string{}
+	line := ""
+	lineWidth := 0
+	limit := firstWidth
+
+	for _, word := range words {
+		wordWidth := StringWidth(word)
+		if line != "" && lineWidth+1+wordWidth > limit {
+			lines = append(lines, line)
+			line, lineWidth, limit = "", 0, width
+		}
+		if line != "" {
+			line += " "
+			lineWidth++
+		}
+		line += word
+		lineWidth += wordWidth
+	}
+
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
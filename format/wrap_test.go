@@ -0,0 +1,33 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/creichlin/gutil/testin"
+)
+
+func TestWrap(t *testing.T) {
+	testin.RunMapTests(t, "testcases/wrap", func(source, operation string, t *testing.T) string {
+		switch operation {
+		case "wrap":
+			return Wrap(source, 24)
+		case "dedent":
+			return Dedent(source)
+		case "indent":
+			return Indent(source, "> ")
+		case "hanging":
+			return WrapIndented(source, 40, "  -token  ", strings.Repeat(" ", 10))
+		}
+		t.Fatalf("Wrong operation %v", operation)
+		return ""
+	})
+}
+
+func TestDedentTabs(t *testing.T) {
+	actual := Dedent("\t\ta\n      b\n   \n\t  c")
+	expected := "  a\nb\n\nc"
+	if actual != expected {
+		t.Errorf("dedent should be %q but is %q", expected, actual)
+	}
+}
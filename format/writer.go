@@ -0,0 +1,90 @@
+package format
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Writer aligns the text written to it like AlignBlocks but
+// only buffers the lines of blocks that might still grow.
+// As soon as all blocks of the buffered lines are finished,
+// they are aligned and written to the output. Flush must be
+// called after the last write.
+// With Nested option a block is only finished when a line with
+// smaller indentation follows, so top level blocks can grow
+// till the end of the input.
+type Writer struct {
+	output      io.Writer
+	matcher     Matcher
+	options     BlockOptions
+	partitioner *partitioner
+	pending     []string
+	partial     []byte
+}
+
+// NewWriter creates a writer that writes the aligned
+// text to output
+func NewWriter(output io.Writer, matcher Matcher, options BlockOptions) *Writer {
+	return &Writer{
+		output:      output,
+		matcher:     matcher,
+		options:     options,
+		partitioner: newPartitioner(matcher, options),
+	}
+}
+
+func (w *Writer) Write(data []byte) (int, error) {
+	written := 0
+	for len(data) > 0 {
+		newLine := bytes.IndexByte(data, '\n')
+		if newLine == -1 {
+			w.partial = append(w.partial, data...)
+			written += len(data)
+			break
+		}
+
+		line := string(append(w.partial, data[:newLine]...))
+		w.partial = w.partial[:0]
+		data = data[newLine+1:]
+		written += newLine + 1
+
+		if err := w.addLine(line); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Flush aligns and writes all buffered text. The text after
+// the last newline is written as last line without newline.
+func (w *Writer) Flush() error {
+	w.partitioner.add(len(w.pending), string(w.partial))
+	lines := w.partitioner.align(append(w.pending, string(w.partial)))
+
+	w.partitioner = newPartitioner(w.matcher, w.options)
+	w.pending = nil
+	w.partial = w.partial[:0]
+
+	_, err := io.WriteString(w.output, strings.Join(lines, "\n"))
+	return err
+}
+
+func (w *Writer) addLine(line string) error {
+	index := len(w.pending)
+	w.partitioner.add(index, line)
+	w.pending = append(w.pending, line)
+
+	if index == 0 || !w.partitioner.settled(index) {
+		return nil
+	}
+
+	// all lines before the new one are in finished blocks
+	lines := w.partitioner.align(w.pending)[:index]
+	w.partitioner = newPartitioner(w.matcher, w.options)
+	w.partitioner.add(0, line)
+	w.pending = []string{line}
+
+	_, err := io.WriteString(w.output, strings.Join(lines, "\n")+"\n")
+	return err
+}
@@ -0,0 +1,73 @@
+package format
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriterMatchesAlignBlocks(t *testing.T) {
+	files, err := filepath.Glob("testcases/*/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := []BlockOptions{
+		{},
+		{BlankLines: BlankBreaks},
+		{BlankLines: BlankContinues},
+		{MissingTokenBreaks: true},
+		{Nested: true},
+		{Nested: true, BlankLines: BlankContinues, MissingTokenBreaks: true},
+	}
+
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, option := range options {
+			expected := AlignBlocks(string(content), Token(":"), option)
+
+			for _, chunkSize := range []int{1, 7, len(content) + 1} {
+				buffer := &bytes.Buffer{}
+				writer := NewWriter(buffer, Token(":"), option)
+				for start := 0; start < len(content); start += chunkSize {
+					end := start + chunkSize
+					if end > len(content) {
+						end = len(content)
+					}
+					if _, err := writer.Write(content[start:end]); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := writer.Flush(); err != nil {
+					t.Fatal(err)
+				}
+
+				if buffer.String() != expected {
+					t.Errorf("%v with %+v in chunks of %v differs\nexpected:\n%v\nactual:\n%v",
+						file, option, chunkSize, expected, buffer.String())
+				}
+			}
+		}
+	}
+}
+
+func TestWriterFlushesFinishedBlocks(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer := NewWriter(buffer, Token(":"), BlockOptions{})
+
+	writer.Write([]byte("a: 1\nbbb: 2\n  c: 3\n")) // nolint: errcheck
+	if buffer.String() != "a  : 1\nbbb: 2\n" {
+		t.Errorf("first block should be written but got %q", buffer.String())
+	}
+
+	writer.Write([]byte("  dd: 4")) // nolint: errcheck
+	writer.Flush()                  // nolint: errcheck
+	if buffer.String() != "a  : 1\nbbb: 2\n  c : 3\n  dd: 4" {
+		t.Errorf("all blocks should be written after flush but got %q", buffer.String())
+	}
+}
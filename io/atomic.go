@@ -3,7 +3,7 @@ package io
 import (
 	"io/ioutil"
 	"os"
-	"path"
+	"path/filepath"
 )
 
 // WriteFileAtomic creates a temp file and renames it to to
@@ -20,8 +20,9 @@ import (
 // this will result in an atomically written file, either it's the old one or
 // the new content but not half of the change
 func WriteFileAtomic(filename string, data []byte, perm os.FileMode) (err error) {
-	dir, name := path.Split(filename)
-	tmpFile, err := ioutil.TempFile(dir, name)
+	// the temp file must be in the same directory, renaming
+	// across file systems fails
+	tmpFile, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename))
 	if err != nil {
 		return
 	}
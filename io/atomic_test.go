@@ -35,3 +35,23 @@ func TestWriteFileAtomic(t *testing.T) {
 
 	os.Remove("lala") // nolint: errcheck
 }
+
+// The temp file must be created next to the target, even for
+// bare file names, otherwise renaming across file systems fails
+func TestWriteFileAtomicBareName(t *testing.T) {
+	tmpDir := os.Getenv("TMPDIR")
+	os.Setenv("TMPDIR", "/does/not/exist") // nolint: errcheck
+	defer os.Setenv("TMPDIR", tmpDir)      // nolint: errcheck
+	defer os.Remove("lulu")                // nolint: errcheck
+
+	if err := WriteFileAtomic("lulu", []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile("lulu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "data" {
+		t.Errorf("File should contain data but contains %q", data)
+	}
+}
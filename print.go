@@ -1,16 +1,91 @@
 package gutil
 
 import (
+	"encoding/json"
 	"fmt"
-	"gopkg.in/yaml.v2"
+	"io"
 	"log"
+	"os"
+
+	"github.com/creichlin/gutil/format"
+	"gopkg.in/yaml.v2"
 )
 
-// PrintAsYAML prins the given object formatted as yaml to stdout
+// Output formats supported by PrintAs
+const (
+	OutputYAML       = "yaml"
+	OutputJSON       = "json"
+	OutputPrettyJSON = "pretty-json"
+	OutputTable      = "table"
+)
+
+// PrintAsYAML prints the given object formatted as yaml to stdout
+// if it can't be formatted, the error is logged
 func PrintAsYAML(obj interface{}) {
+	if err := FprintAsYAML(os.Stdout, obj); err != nil {
+		log.Print(err)
+	}
+}
+
+// PrintAs prints the given object to stdout in the given format,
+// one of yaml, json, pretty-json or table
+func PrintAs(format string, obj interface{}) error {
+	return FprintAs(os.Stdout, format, obj)
+}
+
+// FprintAs writes the given object to w in the given format,
+// one of yaml, json, pretty-json or table
+func FprintAs(w io.Writer, format string, obj interface{}) error {
+	switch format {
+	case OutputYAML:
+		return FprintAsYAML(w, obj)
+	case OutputJSON:
+		return FprintAsJSON(w, obj)
+	case OutputPrettyJSON:
+		return FprintAsPrettyJSON(w, obj)
+	case OutputTable:
+		return FprintAsTable(w, obj)
+	}
+	return fmt.Errorf("unknown output format %v", format)
+}
+
+// FprintAsYAML writes the given object formatted as yaml to w
+func FprintAsYAML(w io.Writer, obj interface{}) error {
 	out, err := yaml.Marshal(obj)
 	if err != nil {
-		log.Printf("Failed to print %T as YAML, %v", obj, err)
+		return fmt.Errorf("failed to print %T as YAML, %v", obj, err)
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// FprintAsJSON writes the given object as a single line of json to w
+func FprintAsJSON(w io.Writer, obj interface{}) error {
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("failed to print %T as JSON, %v", obj, err)
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
+// FprintAsPrettyJSON writes the given object as indented json to w
+func FprintAsPrettyJSON(w io.Writer, obj interface{}) error {
+	out, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to print %T as JSON, %v", obj, err)
+	}
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
+// FprintAsTable writes a list of maps or structs as a table to w.
+// Each map key or exported struct field becomes a column.
+func FprintAsTable(w io.Writer, obj interface{}) error {
+	out, err := format.RenderTable(obj)
+	if err != nil {
+		return fmt.Errorf("failed to print %T as table, %v", obj, err)
 	}
-	fmt.Println(string(out))
+	_, err = io.WriteString(w, out)
+	return err
 }
@@ -0,0 +1,57 @@
+package gutil
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFprintAs(t *testing.T) {
+	type record struct {
+		Name string
+		Port int
+	}
+
+	testCases := []struct {
+		format   string
+		obj      interface{}
+		expected string
+	}{
+		{OutputYAML, map[string]int{"a": 1}, "a: 1\n"},
+		{OutputJSON, map[string]int{"a": 1}, "{\"a\":1}\n"},
+		{OutputPrettyJSON, map[string]int{"a": 1}, "{\n  \"a\": 1\n}\n"},
+		{OutputTable, []record{{"web", 80}, {"database", 5432}}, "Name      Port\nweb       80\ndatabase  5432\n"},
+		{OutputTable, []interface{}{
+			map[string]interface{}{"b": 1, "a": "x"},
+			map[string]interface{}{"c": true},
+		}, "a  b  c\nx  1\n      true\n"},
+		{OutputTable, []interface{}{map[string]interface{}{"n": 21540000.0, "f": 0.5}}, "f    n\n0.5  21540000\n"},
+	}
+
+	for _, testCase := range testCases {
+		buffer := &bytes.Buffer{}
+		err := FprintAs(buffer, testCase.format, testCase.obj)
+		if err != nil {
+			t.Errorf("%v failed with %v", testCase.format, err)
+			continue
+		}
+		if buffer.String() != testCase.expected {
+			t.Errorf("%v should be\n%q\nbut is\n%q", testCase.format, testCase.expected, buffer.String())
+		}
+	}
+}
+
+func TestFprintAsErrors(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	if err := FprintAs(buffer, "xml", 1); err == nil {
+		t.Errorf("unknown format should fail")
+	}
+	if err := FprintAsJSON(buffer, make(chan int)); err == nil {
+		t.Errorf("json of a channel should fail")
+	}
+	if err := FprintAsTable(buffer, 5); err == nil {
+		t.Errorf("table of non list should fail")
+	}
+	if buffer.Len() != 0 {
+		t.Errorf("nothing should be written on errors but got %q", buffer.String())
+	}
+}
@@ -0,0 +1,62 @@
+package testin
+
+import "testing"
+
+// RunMapBenchmarks runs the result parts of all files in the
+// folder as benchmarks
+func RunMapBenchmarks(b *testing.B, folder string, process CaseProcess) {
+	Options{}.RunMapBenchmarks(b, folder, process)
+}
+
+// RunMapBenchmarks runs the result parts of all files in the
+// folder selected by the options as benchmarks. They are named
+// like the tests of RunCaseTests and get the same cases. The
+// size of all inputs is reported as bytes processed. Error parts
+// are skipped.
+func (o Options) RunMapBenchmarks(b *testing.B, folder string, process CaseProcess) {
+	err := o.walk(folder, func(file, name string) {
+		b.Run(name, func(b *testing.B) {
+			_, parts, err := o.readFile(file)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			size := 0
+			for _, input := range parts.inputs {
+				size += len(input)
+			}
+
+			for _, key := range parts.keys() {
+				section := parts.section(key, false)
+				if section.expectError {
+					continue
+				}
+				expected := parts.results[key]
+				c := &Case{Name: section.name, Args: section.args, Inputs: parts.inputs}
+
+				b.Run(key, func(b *testing.B) {
+					if o.CheckBenchmarks {
+						result := process(c, b)
+						equal, expectedText, actualText, err := compare(o.comparison(section), expected, result)
+						if err != nil {
+							b.Fatalf("Failed to compare result for %v at %v:%v, %v", key, file, section.line, err)
+						}
+						if !equal {
+							b.Fatalf("Failed to generate expected result for %v at %v:%v:\n%v",
+								key, file, section.line, diff(expectedText, actualText))
+						}
+					}
+
+					b.SetBytes(int64(size))
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						process(c, b)
+					}
+				})
+			}
+		})
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+}
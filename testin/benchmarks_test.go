@@ -0,0 +1,11 @@
+package testin
+
+import "testing"
+
+func BenchmarkMapBenchmarks(b *testing.B) {
+	Options{CheckBenchmarks: true}.RunMapBenchmarks(b, "testcases/args", repeatCase)
+}
+
+func BenchmarkMapBenchmarksInputs(b *testing.B) {
+	Options{CheckBenchmarks: true}.RunMapBenchmarks(b, "testcases/inputs", renderCase)
+}
@@ -0,0 +1,124 @@
+package testin
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/creichlin/gutil/treedata/sanitize"
+	"gopkg.in/yaml.v3"
+)
+
+// Comparison modes for result parts, selected with the compare
+// argument of a part or the Compare option
+const (
+	// CompareExact compares results byte by byte
+	CompareExact = "exact"
+	// CompareTrimmed ignores trailing whitespace of lines
+	// and leading and trailing blank lines
+	CompareTrimmed = "trimmed"
+	// CompareJSON compares the data of json documents,
+	// ignoring key order and formatting
+	CompareJSON = "json"
+	// CompareYAML compares the data of yaml documents like
+	// treedata loads them, ignoring key order and formatting.
+	// Integers and floats differ, so 1 and 1.0 are not the same.
+	CompareYAML = "yaml"
+	// CompareRegexp matches the whole result with the expected
+	// content as regular expression. Error parts with match=regexp
+	// use the same rule.
+	CompareRegexp = "regexp"
+)
+
+// isComparison reports if mode is a known comparison mode
+func isComparison(mode string) bool {
+	switch mode {
+	case CompareExact, CompareTrimmed, CompareJSON, CompareYAML, CompareRegexp:
+		return true
+	}
+	return false
+}
+
+// compare reports if actual matches expected in the given mode.
+// It also returns both in the form they were compared in, to
+// show differences.
+func compare(mode, expected, actual string) (bool, string, string, error) {
+	switch mode {
+	case "", CompareExact:
+		return expected == actual, expected, actual, nil
+
+	case CompareTrimmed:
+		expected, actual = trimLines(expected), trimLines(actual)
+		return expected == actual, expected, actual, nil
+
+	case CompareJSON, CompareYAML:
+		expectedTree, expected, err := canonical(expected, mode)
+		if err != nil {
+			return false, "", "", fmt.Errorf("expected result is not valid %v, %v", mode, err)
+		}
+		actualTree, actual, err := canonical(actual, mode)
+		if err != nil {
+			return false, "", "", fmt.Errorf("result is not valid %v, %v", mode, err)
+		}
+		// the trees are compared, yaml writes 1.0 as 1
+		return reflect.DeepEqual(expectedTree, actualTree), expected, actual, nil
+
+	case CompareRegexp:
+		pattern, err := anchoredRegexp(expected)
+		if err != nil {
+			return false, "", "", fmt.Errorf("expected result is not a valid pattern, %v", err)
+		}
+		return pattern.MatchString(actual), expected, actual, nil
+	}
+	return false, "", "", fmt.Errorf("unknown comparison %v", mode)
+}
+
+// trimLines removes trailing whitespace from all lines
+// and leading and trailing blank lines
+func trimLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// canonical parses the json or yaml document and returns the
+// tree, sanitized like treedata does it when loading files, and
+// it's serialization with sorted keys and consistent formatting
+func canonical(document string, mode string) (interface{}, string, error) {
+	var tree interface{}
+	unmarshal := json.Unmarshal
+	if mode == CompareYAML {
+		unmarshal = yaml.Unmarshal
+	}
+	if err := unmarshal([]byte(document), &tree); err != nil {
+		return nil, "", err
+	}
+
+	tree, err := sanitize.Normalize(tree)
+	if err != nil {
+		return nil, "", err
+	}
+	tree = sanitize.ForJSON(tree)
+
+	var data []byte
+	if mode == CompareJSON {
+		data, err = json.MarshalIndent(tree, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(tree)
+	}
+	return tree, string(data), err
+}
+
+// anchoredRegexp compiles a pattern that has to match the whole text
+func anchoredRegexp(pattern string) (*regexp.Regexp, error) {
+	// compile it unchanged first, for errors that show the pattern
+	if _, err := regexp.Compile(pattern); err != nil {
+		return nil, err
+	}
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
@@ -0,0 +1,78 @@
+package testin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCompare(t *testing.T) {
+	testCases := []struct {
+		mode     string
+		expected string
+		actual   string
+		equal    bool
+	}{
+		{CompareExact, "a ", "a", false},
+		{CompareTrimmed, "\na \n\tb\t\n\n", "a\n\tb", true},
+		{CompareTrimmed, "a b", "a  b", false},
+		{CompareJSON, `{"a": [1, 2], "b": null}`, `{"b":null,"a":[1.0,2]}`, true},
+		{CompareJSON, `{"a": [1, 2]}`, `{"a": [2, 1]}`, false},
+		{CompareYAML, "a: 1\nb: {c: x}", "b:\n  c: x\na: 1\n", true},
+		{CompareYAML, "a: 1", "a: '1'", false},
+		{CompareYAML, "a: 1", "a: 1.0", false},
+		{CompareYAML, "on: 2001-12-14", "'on': 2001-12-14T00:00:00Z", true},
+		{CompareRegexp, `a\d+`, "a12", true},
+		{CompareRegexp, `a\d+`, "a12b", false},
+	}
+
+	for _, testCase := range testCases {
+		equal, _, _, err := compare(testCase.mode, testCase.expected, testCase.actual)
+		if err != nil {
+			t.Errorf("Comparing %q and %q with %v failed with %v", testCase.expected, testCase.actual, testCase.mode, err)
+		} else if equal != testCase.equal {
+			t.Errorf("Comparing %q and %q with %v should be %v", testCase.expected, testCase.actual, testCase.mode, testCase.equal)
+		}
+	}
+
+	if _, _, _, err := compare(CompareJSON, "{}", "{"); err == nil {
+		t.Errorf("Comparing invalid json should fail")
+	}
+	if _, _, _, err := compare("fuzzy", "", ""); err == nil {
+		t.Errorf("Comparing with unknown mode should fail")
+	}
+}
+
+func TestCompareModes(t *testing.T) {
+	RunCaseTests(t, "testcases/compare", func(c *Case, t testing.TB) string {
+		data := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(c.Inputs[""]), &data); err != nil {
+			t.Fatal(err)
+		}
+		switch c.Name {
+		case "json":
+			out, err := json.Marshal(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(out)
+		case "yaml":
+			out, err := yaml.Marshal(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(out)
+		case "text":
+			ports := []string{}
+			for _, port := range data["ports"].([]interface{}) {
+				ports = append(ports, fmt.Sprint(port))
+			}
+			return fmt.Sprintf("name: %v\nports: %v\n", data["name"], strings.Join(ports, ", "))
+		}
+		t.Fatalf("Wrong operation %v", c.Name)
+		return ""
+	})
+}
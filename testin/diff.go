@@ -0,0 +1,157 @@
+package testin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes
+const diffContext = 3
+
+// line is a line of text, noNewline is set if it's the last
+// line and the text does not end with a newline
+type line struct {
+	text      string
+	noNewline bool
+}
+
+// edit is a line of the edit script, kind is one of ' ', '-' or '+'.
+// from and to are the 0 based line indices in expected and actual,
+// -1 if the line is not part of it.
+type edit struct {
+	kind     byte
+	from, to int
+	line     line
+}
+
+func splitLines(text string) []line {
+	if text == "" {
+		return nil
+	}
+	noNewline := !strings.HasSuffix(text, "\n")
+	texts := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	lines := make([]line, len(texts))
+	for i, text := range texts {
+		lines[i] = line{text: text}
+	}
+	lines[len(lines)-1].noNewline = noNewline
+	return lines
+}
+
+// diff returns a line based unified diff from expected to actual
+// with line numbers. Tabs, trailing whitespace and a missing final
+// newline are made visible.
+func diff(expected, actual string) string {
+	edits := editScript(splitLines(expected), splitLines(actual))
+
+	out := "--- expected\n+++ actual\n"
+	for start := 0; start < len(edits); {
+		if edits[start].kind == ' ' {
+			start++
+			continue
+		}
+		// extend the hunk while changes are close enough
+		// to share their context
+		end := start
+		for next := start; next < len(edits); next++ {
+			if edits[next].kind != ' ' {
+				end = next + 1
+			} else if next-end >= 2*diffContext {
+				break
+			}
+		}
+		from := start - diffContext
+		if from < 0 {
+			from = 0
+		}
+		to := end + diffContext
+		if to > len(edits) {
+			to = len(edits)
+		}
+		out += renderHunk(edits[from:to])
+		start = to
+	}
+	return out
+}
+
+func renderHunk(edits []edit) string {
+	fromStart, fromCount, toStart, toCount := -1, 0, -1, 0
+	for _, e := range edits {
+		if e.from != -1 {
+			if fromStart == -1 {
+				fromStart = e.from
+			}
+			fromCount++
+		}
+		if e.to != -1 {
+			if toStart == -1 {
+				toStart = e.to
+			}
+			toCount++
+		}
+	}
+
+	out := fmt.Sprintf("@@ -%v,%v +%v,%v @@\n", fromStart+1, fromCount, toStart+1, toCount)
+	for _, e := range edits {
+		out += fmt.Sprintf("%c%5v%5v | %v\n", e.kind, lineNumber(e.from), lineNumber(e.to), visible(e.line.text))
+		if e.line.noNewline {
+			out += "\\ No newline at end of file\n"
+		}
+	}
+	return out
+}
+
+func lineNumber(index int) string {
+	if index == -1 {
+		return ""
+	}
+	return fmt.Sprint(index + 1)
+}
+
+// visible replaces tabs, carriage returns and trailing
+// spaces by visible markers
+func visible(text string) string {
+	trimmed := strings.TrimRight(text, " ")
+	text = trimmed + strings.Repeat("·", len(text)-len(trimmed))
+	text = strings.Replace(text, "\t", "→", -1)
+	return strings.Replace(text, "\r", "␍", -1)
+}
+
+// editScript returns the edits from a to b using the longest
+// common subsequence of lines. It needs quadratic space which
+// is fine for the size of test results.
+func editScript(a, b []line) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	edits := []edit{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', i, j, a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', i, -1, a[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', -1, j, b[j]})
+			j++
+		}
+	}
+	return edits
+}
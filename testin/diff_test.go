@@ -0,0 +1,58 @@
+package testin
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		expected string
+		actual   string
+		diff     string
+	}{
+		{"a\nb\nc\n", "a\nB\nc\n", `--- expected
++++ actual
+@@ -1,3 +1,3 @@
+     1    1 | a
+-    2      | b
++         2 | B
+     3    3 | c
+`},
+		{"a \n\tb", "a\n\tb\n", `--- expected
++++ actual
+@@ -1,2 +1,2 @@
+-    1      | a·
+-    2      | →b
+\ No newline at end of file
++         1 | a
++         2 | →b
+`},
+		{"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n", "1\nx\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny\n", `--- expected
++++ actual
+@@ -1,5 +1,5 @@
+     1    1 | 1
+-    2      | 2
++         2 | x
+     3    3 | 3
+     4    4 | 4
+     5    5 | 5
+@@ -9,4 +9,4 @@
+     9    9 | 9
+    10   10 | 10
+    11   11 | 11
+-   12      | 12
++        12 | y
+`},
+		{"", "a", `--- expected
++++ actual
+@@ -0,0 +1,1 @@
++         1 | a
+\ No newline at end of file
+`},
+	}
+
+	for _, testCase := range testCases {
+		actual := diff(testCase.expected, testCase.actual)
+		if actual != testCase.diff {
+			t.Errorf("Diff of %q and %q should be\n%v\nbut is\n%v", testCase.expected, testCase.actual, testCase.diff, actual)
+		}
+	}
+}
@@ -1,24 +1,80 @@
 package testin
 
 import (
+	"flag"
+	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+
+	gio "github.com/creichlin/gutil/io"
 )
 
+var updateFlag = flag.Bool("testin.update", false, "rewrite the result parts of testin files with the actual results")
+
+// updating reports if result parts should be rewritten instead of
+// compared. It's enabled by the -testin.update flag or by setting
+// the environment variable TESTIN_UPDATE to a non empty value.
+func updating() bool {
+	return *updateFlag || os.Getenv("TESTIN_UPDATE") != ""
+}
+
 type process func(source string, operation string, t *testing.T) string
 
+// Case is a result part of a test file together with
+// all inputs of the file
+type Case struct {
+	// Name is the name of the result part
+	Name string
+	// Args are the arguments of the result part
+	Args map[string]string
+	// Inputs contains the input parts by name, the
+	// main part is stored with the empty name
+	Inputs map[string]string
+}
+
+// CaseProcess calculates the result of a case. It gets a test
+// or a benchmark, so it can be used with RunCaseTests and
+// RunMapBenchmarks.
+type CaseProcess func(c *Case, tb testing.TB) string
+
+// ErrorCaseProcess calculates the result of a case or fails
+type ErrorCaseProcess func(c *Case, tb testing.TB) (string, error)
+
+// Options configure how map tests are run. RunMapTests and
+// RunMapTest use the zero value.
+type Options struct {
+	// RequireResults fails files without any result part
+	RequireResults bool
+	// Recursive includes files in sub folders
+	Recursive bool
+	// Pattern selects files by a filepath.Match pattern
+	// on their name like *.txt, all files if empty
+	Pattern string
+	// Parallel runs files and result parts in parallel
+	Parallel bool
+	// Compare is the comparison mode for result parts without
+	// compare argument, exact if empty. See CompareExact.
+	Compare string
+	// CheckBenchmarks compares the result of each part once
+	// before it's benchmarked
+	CheckBenchmarks bool
+}
+
 func RunMapTests(t *testing.T, folder string, process process) {
-	files, err := ioutil.ReadDir(folder)
-	if err != nil {
-		panic(err)
-	}
+	Options{}.RunMapTests(t, folder, process)
+}
 
-	for _, file := range files {
-		RunMapTest(t, filepath.Join(folder, file.Name()), process)
-	}
+// RunMapTests runs RunMapTest for all files in the folder
+// selected by the options
+func (o Options) RunMapTests(t *testing.T, folder string, process process) {
+	o.RunCaseTests(t, folder, mapProcess(process))
 }
 
 // RunMapTest will read the given file and split it's
@@ -33,77 +89,553 @@ func RunMapTests(t *testing.T, folder string, process process) {
 // part. The result of the process is the transformation
 // from the source part which then is compared with the defined
 // result part.
+// In update mode, see updating, the result parts are replaced
+// by the actual results and the file is rewritten.
+// Files with the .txtar extension use txtar headers
+// -- name --
+// instead of the banners, so the content can contain hash lines.
 func RunMapTest(t *testing.T, file string, process process) {
-	bytes, err := ioutil.ReadFile(file)
+	Options{}.RunMapTest(t, file, process)
+}
+
+// RunMapTest works like the RunMapTest func using the options
+func (o Options) RunMapTest(t *testing.T, file string, process process) {
+	o.RunCaseTest(t, file, mapProcess(process))
+}
+
+// mapProcess calls process with the main part as source
+func mapProcess(process process) CaseProcess {
+	return func(c *Case, tb testing.TB) string {
+		// map processes are only run by tests
+		return process(c.Inputs[""], c.Name, tb.(*testing.T))
+	}
+}
+
+// RunCaseTests runs RunCaseTest for all files in the folder
+func RunCaseTests(t *testing.T, folder string, process CaseProcess) {
+	Options{}.RunCaseTests(t, folder, process)
+}
+
+// RunCaseTests runs RunCaseTest for all files in the folder
+// selected by the options
+func (o Options) RunCaseTests(t *testing.T, folder string, process CaseProcess) {
+	o.RunErrorCaseTests(t, folder, caseProcess(process))
+}
+
+// caseProcess wraps a process that never fails
+func caseProcess(process CaseProcess) ErrorCaseProcess {
+	return func(c *Case, tb testing.TB) (string, error) {
+		return process(c, tb), nil
+	}
+}
+
+// RunErrorCaseTests runs RunErrorCaseTest for all files in the folder
+func RunErrorCaseTests(t *testing.T, folder string, process ErrorCaseProcess) {
+	Options{}.RunErrorCaseTests(t, folder, process)
+}
+
+// RunErrorCaseTests runs RunErrorCaseTest for all files in the
+// folder selected by the options. Hidden files and folders,
+// starting with a dot, are skipped. The tests are named by the
+// path relative to the folder.
+func (o Options) RunErrorCaseTests(t *testing.T, folder string, process ErrorCaseProcess) {
+	err := o.walk(folder, func(file, name string) {
+		o.runFile(t, file, name, process)
+	})
 	if err != nil {
 		t.Fatal(err)
 	}
+}
+
+// walk calls run with the path and the slash separated
+// relative name of each file in the folder selected
+// by the options
+func (o Options) walk(folder string, run func(file, name string)) error {
+	return filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == folder {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") || (info.IsDir() && !o.Recursive) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if o.Pattern != "" {
+			matches, err := filepath.Match(o.Pattern, info.Name())
+			if err != nil || !matches {
+				return err
+			}
+		}
+
+		name, err := filepath.Rel(folder, path)
+		if err != nil {
+			return err
+		}
+		run(path, filepath.ToSlash(name))
+		return nil
+	})
+}
+
+// RunCaseTest works like RunMapTest but supports additional
+// named input parts. Their delimiter names start with a <
+// #########
+// # < name
+// #########
+// while result names can start with a > to make them
+// easier to distinguish. The process func gets all inputs
+// and the main part, which is the input with the empty name.
+// Result parts can have arguments after the name
+// #########
+// # name key=value other="quoted value"
+// #########
+// which are passed to the process func. So the same name can
+// be used multiple times with different arguments.
+// The compare argument is not passed, it selects how results
+// are compared, see CompareExact.
+func RunCaseTest(t *testing.T, file string, process CaseProcess) {
+	Options{}.RunCaseTest(t, file, process)
+}
+
+// RunCaseTest works like the RunCaseTest func using the options
+func (o Options) RunCaseTest(t *testing.T, file string, process CaseProcess) {
+	o.runFile(t, file, file, caseProcess(process))
+}
+
+// RunErrorCaseTest works like RunCaseTest but the process can
+// return an error. The error is compared with error parts
+// #########
+// # error: name match=substring
+// #########
+// where match is exact, substring or regexp and defaults to
+// exact. Like CompareRegexp, a regexp has to match the whole
+// message. Error parts fail if there is no error and result
+// parts fail on errors.
+func RunErrorCaseTest(t *testing.T, file string, process ErrorCaseProcess) {
+	Options{}.RunErrorCaseTest(t, file, process)
+}
+
+// RunErrorCaseTest works like the RunErrorCaseTest func using the options
+func (o Options) RunErrorCaseTest(t *testing.T, file string, process ErrorCaseProcess) {
+	o.runFile(t, file, file, process)
+}
+
+// runFile runs the result parts of the file as subtests of
+// a test with the given name
+func (o Options) runFile(t *testing.T, file, name string, process ErrorCaseProcess) {
+	t.Run(name, func(t *testing.T) {
+		if o.Parallel {
+			t.Parallel()
+		}
+
+		content, parts, err := o.readFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// results are updated by parallel subtests
+		lock := &sync.Mutex{}
+
+		for _, key := range parts.keys() {
+			key := key
+			section := parts.section(key, false)
+			expected := parts.results[key]
+
+			t.Run(key, func(t *testing.T) {
+				if o.Parallel {
+					t.Parallel()
+				}
+
+				result, err := process(&Case{Name: section.name, Args: section.args, Inputs: parts.inputs}, t)
+				if err != nil && !section.expectError {
+					t.Fatalf("Failed to generate result for %v at %v:%v, %v", key, file, section.line, err)
+				}
+				if err == nil && section.expectError {
+					t.Fatalf("Expected an error for %v at %v:%v but got:\n%v", key, file, section.line, result)
+				}
+
+				mode := o.comparison(section)
+
+				expectedText, actualText := expected, result
+				if section.expectError {
+					if section.matches(err.Error(), expected) {
+						return
+					}
+					result = err.Error()
+					actualText = result
+				} else {
+					equal, comparedExpected, comparedActual, err := compare(mode, expected, result)
+					if err != nil {
+						t.Fatalf("Failed to compare result for %v at %v:%v, %v", key, file, section.line, err)
+					}
+					if equal {
+						return
+					}
+					expectedText, actualText = comparedExpected, comparedActual
+				}
+
+				if updating() {
+					if section.match == "regexp" || mode == CompareRegexp {
+						result = regexp.QuoteMeta(result)
+					}
+					lock.Lock()
+					parts.update(key, result)
+					lock.Unlock()
+					t.Logf("Updated %v for %v", section.kind(), key)
+					return
+				}
+				t.Errorf("Failed to generate expected %v for %v at %v:%v:\n%v",
+					section.kind(), key, file, section.line, diff(expectedText, actualText))
+			})
+		}
+
+		// runs after all subtests, including parallel ones, are done
+		t.Cleanup(func() {
+			if updated := parts.String(); updated != content {
+				info, err := os.Stat(file)
+				if err == nil {
+					err = gio.WriteFileAtomic(file, []byte(updated), info.Mode().Perm())
+				}
+				if err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	})
+}
+
+// comparison returns the comparison mode for a result part
+func (o Options) comparison(s *section) string {
+	if s.compare != "" {
+		return s.compare
+	}
+	return o.Compare
+}
+
+// readFile reads and parses a test file, the format
+// is selected by the extension
+func (o Options) readFile(file string) (string, *parts, error) {
+	bytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", nil, err
+	}
 	content := string(bytes)
 
-	parts, err := splitParts(content)
+	split := splitParts
+	if filepath.Ext(file) == ".txtar" {
+		split = splitTxtarParts
+	}
+	parts, err := split(content)
 	if err != nil {
-		t.Fatal(err)
+		return "", nil, fmt.Errorf("%v:%v", file, err)
 	}
+	if o.RequireResults && len(parts.results) == 0 {
+		return "", nil, fmt.Errorf("%v:1: no result parts found", file)
+	}
+	return content, parts, nil
+}
+
+type parts struct {
+	main     string
+	results  map[string]string
+	inputs   map[string]string // including main with the empty name
+	lines    []string          // lines of the main part
+	sections []*section        // input and result parts in file order
+	newline  bool              // final newline not part of the last part
+}
 
+// section is an input, result or error part with it's delimiter
+type section struct {
+	key         string // name and arguments
+	name        string
+	args        map[string]string
+	input       bool
+	expectError bool
+	match       string         // how errors are matched
+	compare     string         // how results are compared
+	pattern     *regexp.Regexp // for regexp matches
+	line        int            // line number of the name in the delimiter, starting with 1
+	delimiter   []string
+	lines       []string
+}
+
+// keys returns the sorted keys of the result and error parts
+func (p *parts) keys() []string {
 	keys := []string{}
-	for key := range parts.results {
+	for key := range p.results {
 		keys = append(keys, key)
 	}
 	sort.Strings(keys)
+	return keys
+}
 
-	for _, key := range keys {
-		t.Run(file+"-"+key, func(t *testing.T) {
-			result := process(parts.main, key, t)
-			if result != parts.results[key] {
-				t.Errorf("Failed to generate expected result for %v:\nexpected:\n%v\nactual:\n%v", key, parts.results[key], result)
-			}
-		})
+// section returns the first input or result part with the given key
+func (p *parts) section(key string, input bool) *section {
+	for _, section := range p.sections {
+		if section.key == key && section.input == input {
+			return section
+		}
 	}
+	return nil
 }
 
-type parts struct {
-	main    string
-	results map[string]string
+// update replaces the content of all result parts with the given key
+func (p *parts) update(key, result string) {
+	p.results[key] = result
+	for _, section := range p.sections {
+		if section.key == key && !section.input {
+			section.lines = strings.Split(result, "\n")
+		}
+	}
 }
 
+// String returns the file content the parts were created from
+func (p *parts) String() string {
+	lines := append([]string{}, p.lines...)
+	for _, section := range p.sections {
+		lines = append(lines, section.delimiter...)
+		lines = append(lines, section.lines...)
+	}
+	if p.newline {
+		return strings.Join(lines, "\n") + "\n"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitParts parses the content into the main part and the result
+// parts. Errors are prefixed with the line number they occur in.
 func splitParts(content string) (*parts, error) {
+	return splitPartsWith(content, bannerLimiter)
+}
+
+// limiter returns the text of the delimiter at the index and
+// how many lines it spans or false if there is no delimiter
+type limiter func(lines []string, index int) (string, int, bool)
+
+func bannerLimiter(lines []string, index int) (string, int, bool) {
+	name, found := isLimiter(lines, index)
+	return name, 3, found
+}
+
+// splitPartsWith works like splitParts using the given delimiters
+func splitPartsWith(content string, isLimiter limiter) (*parts, error) {
 
 	result := &parts{
 		results: map[string]string{},
+		inputs:  map[string]string{},
 	}
-	lastIndex := 0
-	nextName := ""
+	var current *section
 
 	lines := strings.Split(content, "\n")
 	for index := 0; index < len(lines); index++ {
-		if name, isLimiter := isLimiter(lines, index); isLimiter {
-			if lastIndex == 0 {
-				result.main = strings.Join(lines[:index], "\n")
-			} else {
-				result.results[nextName] = strings.Join(lines[lastIndex:index], "\n")
+		if key, size, isLimiter := isLimiter(lines, index); isLimiter {
+			// the name is in the middle of the delimiter
+			line := index + size/2 + 1
+			next, err := newSection(key)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", line, err)
+			}
+			if previous := result.section(next.key, next.input); previous != nil {
+				return nil, fmt.Errorf("%v: %v part %v is already defined in line %v", line, next.kind(), next.key, previous.line)
 			}
-			lastIndex = index + 3
-			nextName = name
+			next.line = line
+			next.delimiter = lines[index : index+size]
+			current = next
+			result.sections = append(result.sections, current)
+			index += size - 1
+			continue
+		}
+		if current == nil {
+			result.lines = append(result.lines, lines[index])
+		} else {
+			current.lines = append(current.lines, lines[index])
 		}
 	}
-	if lastIndex == 0 {
-		result.main = content
-	} else {
-		result.results[nextName] = strings.Join(lines[lastIndex:], "\n")
+
+	result.main = strings.Join(result.lines, "\n")
+	result.inputs[""] = result.main
+	for _, section := range result.sections {
+		content := strings.Join(section.lines, "\n")
+		if section.input {
+			result.inputs[section.key] = content
+		} else {
+			result.results[section.key] = content
+		}
+		if section.match == "regexp" {
+			pattern, err := anchoredRegexp(content)
+			if err != nil {
+				return nil, fmt.Errorf("%v: error part %v has invalid pattern, %v", section.line, section.key, err)
+			}
+			section.pattern = pattern
+		}
 	}
 
 	return result, nil
 }
 
+// newSection creates a part from the text of it's delimiter.
+// Input names start with a <, result names can start with a >
+// and error names with error:.
+func newSection(key string) (*section, error) {
+	s := &section{}
+	if strings.HasPrefix(key, "<") || strings.HasPrefix(key, ">") {
+		s.input = key[0] == '<'
+		key = strings.TrimSpace(key[1:])
+	}
+	s.key = key
+	s.expectError = !s.input && strings.HasPrefix(key, "error:")
+
+	name, args, err := parseHeader(strings.TrimPrefix(key, "error:"))
+	if err != nil {
+		return nil, fmt.Errorf("%v part %v has invalid arguments, %v", s.kind(), key, err)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%v part has no name", s.kind())
+	}
+	if s.input && len(args) > 0 {
+		return nil, fmt.Errorf("input part %v can't have arguments", name)
+	}
+	if !s.input && !s.expectError {
+		s.compare = args["compare"]
+		delete(args, "compare")
+		if s.compare != "" && !isComparison(s.compare) {
+			return nil, fmt.Errorf("result part %v has unknown comparison %v", key, s.compare)
+		}
+	}
+	if s.expectError {
+		s.match = args["match"]
+		delete(args, "match")
+		switch s.match {
+		case "":
+			s.match = "exact"
+		case "exact", "substring", "regexp":
+		default:
+			return nil, fmt.Errorf("error part %v has unknown match %v", key, s.match)
+		}
+	}
+	s.name = name
+	s.args = args
+	return s, nil
+}
+
+// kind returns the kind of part for messages
+func (s *section) kind() string {
+	if s.input {
+		return "input"
+	}
+	if s.expectError {
+		return "error"
+	}
+	return "result"
+}
+
+// matches reports if the message of an error matches
+// the expected content of an error part
+func (s *section) matches(message, expected string) bool {
+	switch s.match {
+	case "substring":
+		return strings.Contains(message, expected)
+	case "regexp":
+		return s.pattern.MatchString(message)
+	}
+	return message == expected
+}
+
+// bannerName returns the text of a banner line. The closing #
+// of the banner is only removed if it's separated by a space, so
+// unquoted argument values can end with a #.
+func bannerName(line string) string {
+	name := strings.TrimRight(strings.TrimLeft(line, " #"), " ")
+	trimmed := strings.TrimRight(name, "#")
+	if trimmed == "" || strings.HasSuffix(trimmed, " ") {
+		name = trimmed
+	}
+	return strings.TrimSpace(name)
+}
+
 func isLimiter(lines []string, index int) (string, bool) {
 	// we need at least three lines left for an additional part
 	if index+2 < len(lines) {
 		if strings.HasPrefix(lines[index], "#####") &&
 			strings.HasPrefix(lines[index+2], "#####") &&
 			strings.HasPrefix(lines[index+1], "#") {
-			name := strings.Trim(lines[index+1], " #")
-			return name, true
+			return bannerName(lines[index+1]), true
 		}
 	}
 	return "", false
 }
+
+// parseHeader splits the text of a delimiter into the name, which
+// are all words before the first argument, and the arguments.
+// Arguments have the form key=value where value can be a double
+// quoted go string.
+func parseHeader(text string) (string, map[string]string, error) {
+	words := []string{}
+	args := map[string]string{}
+
+	for text = strings.TrimSpace(text); text != ""; text = strings.TrimSpace(text) {
+		end := strings.IndexAny(text, " =")
+		if end == -1 || text[end] == ' ' {
+			if len(args) > 0 {
+				return "", nil, fmt.Errorf("expected key=value but got %v", strings.Fields(text)[0])
+			}
+			if end == -1 {
+				end = len(text)
+			}
+			words = append(words, text[:end])
+			text = text[end:]
+			continue
+		}
+
+		key := text[:end]
+		text = text[end+1:]
+		if key == "" {
+			return "", nil, fmt.Errorf("argument without key")
+		}
+		if _, exists := args[key]; exists {
+			return "", nil, fmt.Errorf("argument %v is defined twice", key)
+		}
+
+		value := text
+		if strings.HasPrefix(text, "\"") {
+			end := closingQuote(text)
+			if end == -1 {
+				return "", nil, fmt.Errorf("value of %v is not terminated", key)
+			}
+			unquoted, err := strconv.Unquote(text[:end+1])
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid quoted value for %v", key)
+			}
+			value = unquoted
+			text = text[end+1:]
+		} else {
+			if space := strings.IndexByte(text, ' '); space != -1 {
+				value = text[:space]
+			}
+			text = text[len(value):]
+		}
+		args[key] = value
+	}
+
+	return strings.Join(words, " "), args, nil
+}
+
+// closingQuote returns the index of the quote ending the
+// double quoted string at the start of text or -1
+func closingQuote(text string) int {
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
@@ -1,6 +1,16 @@
 package testin
 
-import "testing"
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
 
 func TestSplitParts(t *testing.T) {
 	parts, err := splitParts(`main
@@ -25,3 +35,251 @@ BAR`)
 		t.Errorf("BAR part should be 'bar' but was '%v'", parts.results["BAR"])
 	}
 }
+
+func TestPartsString(t *testing.T) {
+	for _, content := range []string{
+		"main\n#####\n# FOO\n#####\nFOO\n",
+		"#####\n# FOO\n#####\n\nFOO\n\n#####\n# BAR #\n#####",
+		"only main\n",
+		"",
+	} {
+		parts, err := splitParts(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parts.String() != content {
+			t.Errorf("Parts of %q should restore the content but got %q", content, parts.String())
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	file := filepath.Join(dir, "case.txt")
+	content := "main\n##########\n# upper  #\n##########\nwrong\n##########\n# lower  #\n##########\nmain"
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	*updateFlag = true
+	defer func() { *updateFlag = false }()
+
+	RunMapTest(t, file, func(source, operation string, t *testing.T) string {
+		if operation == "upper" {
+			return strings.ToUpper(source)
+		}
+		return source
+	})
+
+	bytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "main\n##########\n# upper  #\n##########\nMAIN\n##########\n# lower  #\n##########\nmain"
+	if string(bytes) != expected {
+		t.Errorf("Updated file should be\n%v\nbut is\n%v", expected, string(bytes))
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Updated file should keep it's permissions but has %v", info.Mode().Perm())
+	}
+}
+
+func TestSplitPartsErrors(t *testing.T) {
+	testCases := []struct {
+		content string
+		err     string
+	}{
+		{"main\n#####\n#\n#####\nFOO", "3: result part has no name"},
+		{"#####\n# FOO\n#####\nFOO\n#####\n# FOO\n#####\nBAR", "6: result part FOO is already defined in line 2"},
+		{"#####\n# <\n#####\nFOO", "2: input part has no name"},
+		{"#####\n# < A b=1\n#####\nFOO", "2: input part A can't have arguments"},
+		{"#####\n# A b=\"1\n#####\nFOO", "2: result part A b=\"1 has invalid arguments, value of b is not terminated"},
+		{"#####\n# A b=1\n#####\n#####\n# A b=1\n#####", "5: result part A b=1 is already defined in line 2"},
+		{"#####\n# error: A match=any\n#####\nFOO", "2: error part error: A match=any has unknown match any"},
+		{"#####\n# error: A match=regexp\n#####\n(\n#####\n# B\n#####", "2: error part error: A match=regexp has invalid pattern, error parsing regexp: missing closing ): `(`"},
+		{"#####\n# error:\n#####", "2: error part has no name"},
+		{"#####\n# A compare=fuzzy\n#####", "2: result part A compare=fuzzy has unknown comparison fuzzy"},
+		{"#####\n# < A\n#####\n#####\n# > A\n#####\n#####\n# <A\n#####", "8: input part A is already defined in line 2"},
+	}
+
+	for _, testCase := range testCases {
+		_, err := splitParts(testCase.content)
+		if err == nil {
+			t.Errorf("Parsing %q should fail", testCase.content)
+		} else if err.Error() != testCase.err {
+			t.Errorf("Parsing %q should fail with '%v' but failed with '%v'", testCase.content, testCase.err, err)
+		}
+	}
+}
+
+func TestRunCaseTests(t *testing.T) {
+	RunCaseTests(t, "testcases/inputs", renderCase)
+}
+
+// renderCase replaces NAME in the template input
+func renderCase(c *Case, tb testing.TB) string {
+	switch c.Name {
+	case "render":
+		return strings.Replace(c.Inputs["template"], "NAME", c.Inputs["name"], -1)
+	case "main":
+		return c.Inputs[""]
+	}
+	tb.Fatalf("Wrong operation %v", c.Name)
+	return ""
+}
+
+func TestParseHeader(t *testing.T) {
+	testCases := []struct {
+		text string
+		name string
+		args map[string]string
+		err  string
+	}{
+		{"indented block aligned", "indented block aligned", map[string]string{}, ""},
+		{` aligned token=":"  width=4 `, "aligned", map[string]string{"token": ":", "width": "4"}, ""},
+		{`quoted a="x \"y\" z" b=`, "quoted", map[string]string{"a": `x "y" z`, "b": ""}, ""},
+		{"a=1", "", map[string]string{"a": "1"}, ""},
+		{"name a=1 b", "", nil, "expected key=value but got b"},
+		{"name a=1 a=2", "", nil, "argument a is defined twice"},
+		{`name a="x`, "", nil, "value of a is not terminated"},
+		{"name =x", "", nil, "argument without key"},
+	}
+
+	for _, testCase := range testCases {
+		name, args, err := parseHeader(testCase.text)
+		if testCase.err != "" {
+			if err == nil || err.Error() != testCase.err {
+				t.Errorf("Parsing %q should fail with '%v' but got %v", testCase.text, testCase.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parsing %q failed with %v", testCase.text, err)
+			continue
+		}
+		if name != testCase.name || !reflect.DeepEqual(args, testCase.args) {
+			t.Errorf("Parsing %q should return %q %v but returned %q %v", testCase.text, testCase.name, testCase.args, name, args)
+		}
+	}
+}
+
+func TestRunCaseTestsWithArgs(t *testing.T) {
+	RunCaseTests(t, "testcases/args", repeatCase)
+}
+
+// repeatCase repeats the main part count times
+func repeatCase(c *Case, tb testing.TB) string {
+	if c.Name != "repeat" {
+		tb.Fatalf("Wrong operation %v", c.Name)
+	}
+	count := 1
+	if c.Args["count"] != "" {
+		var err error
+		count, err = strconv.Atoi(c.Args["count"])
+		if err != nil {
+			tb.Fatal(err)
+		}
+	}
+	parts := []string{}
+	for i := 0; i < count; i++ {
+		parts = append(parts, c.Inputs[""])
+	}
+	return strings.Join(parts, c.Args["separator"])
+}
+
+func TestRunCaseTestsOptions(t *testing.T) {
+	lock := &sync.Mutex{}
+	names := []string{}
+
+	t.Run("all", func(t *testing.T) {
+		Options{Recursive: true, Pattern: "*.txt", Parallel: true}.RunCaseTests(t, "testcases/tree", func(c *Case, t testing.TB) string {
+			lock.Lock()
+			names = append(names, t.Name())
+			lock.Unlock()
+			return c.Inputs[""]
+		})
+	})
+
+	sort.Strings(names)
+	expected := []string{
+		"TestRunCaseTestsOptions/all/a.txt/name",
+		"TestRunCaseTestsOptions/all/sub/b.txt/name",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Tests should be %v but were %v", expected, names)
+	}
+}
+
+func TestRunErrorCaseTests(t *testing.T) {
+	RunErrorCaseTests(t, "testcases/errors", func(c *Case, t testing.TB) (string, error) {
+		if c.Name != "double" {
+			t.Fatalf("Wrong operation %v", c.Name)
+		}
+		if _, found := c.Args["match"]; found {
+			t.Errorf("match argument should not be passed to the process")
+		}
+		input := c.Inputs[""]
+		if c.Args["input"] != "" {
+			input = c.Args["input"]
+		}
+		number, err := strconv.Atoi(input)
+		return strconv.Itoa(number * 2), err
+	})
+}
+
+func TestErrorRegexpMatchesWholeMessage(t *testing.T) {
+	parts, err := splitParts("#####\n# error: A match=regexp\n#####\nparsing \\w+")
+	if err != nil {
+		t.Fatal(err)
+	}
+	section := parts.section("error: A match=regexp", false)
+	if !section.matches("parsing x", parts.results[section.key]) {
+		t.Errorf("Pattern should match the whole message")
+	}
+	if section.matches("failed parsing x", parts.results[section.key]) {
+		t.Errorf("Pattern should not match a part of the message")
+	}
+}
+
+func TestRequireResults(t *testing.T) {
+	file := "testcases/noresults/case1.txt"
+
+	_, _, err := Options{RequireResults: true}.readFile(file)
+	if err == nil || err.Error() != file+":1: no result parts found" {
+		t.Errorf("File without results should fail with '%v:1: no result parts found' but got %v", file, err)
+	}
+
+	if _, _, err := (Options{}).readFile(file); err != nil {
+		t.Errorf("File without results should be accepted without RequireResults but got %v", err)
+	}
+	RunMapTest(t, file, func(source, operation string, t *testing.T) string {
+		t.Fatalf("No part should be run but got %v", operation)
+		return ""
+	})
+}
+
+func TestBannerName(t *testing.T) {
+	testCases := map[string]string{
+		"# name                   #": "name",
+		"## name ##":                 "name",
+		"# name":                     "name",
+		"# seq tokens==,#":           "seq tokens==,#",
+		"# seq tokens==,#   #":       "seq tokens==,#",
+		"#####":                      "",
+	}
+	for line, expected := range testCases {
+		if name := bannerName(line); name != expected {
+			t.Errorf("Name of %q should be %q but is %q", line, expected, name)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package testin
+
+import "strings"
+
+// splitTxtarParts parses content in the txtar format
+// -- name --
+// where the comment is the main part and every file a part.
+// Names have the same meaning as in the banner format. Like
+// there, the line break before a delimiter or the end of the
+// file is not part of the content.
+func splitTxtarParts(content string) (*parts, error) {
+	newline := strings.HasSuffix(content, "\n")
+	parts, err := splitPartsWith(strings.TrimSuffix(content, "\n"), txtarLimiter)
+	if err != nil {
+		return nil, err
+	}
+	parts.newline = newline
+	return parts, nil
+}
+
+func txtarLimiter(lines []string, index int) (string, int, bool) {
+	line := lines[index]
+	if len(line) >= 6 && strings.HasPrefix(line, "-- ") && strings.HasSuffix(line, " --") {
+		return strings.TrimSpace(line[3 : len(line)-3]), 1, true
+	}
+	return "", 0, false
+}
@@ -0,0 +1,85 @@
+package testin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitTxtarParts(t *testing.T) {
+	content := "main\n#####\n# FOO\n#####\n-- FOO --\nFOO\n\n-- < in --\n-- BAR --\nBAR\n"
+	parts, err := splitTxtarParts(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts.main != "main\n#####\n# FOO\n#####" {
+		t.Errorf("Main part should be main and the banner but was '%v'", parts.main)
+	}
+	if parts.results["FOO"] != "FOO\n" {
+		t.Errorf("FOO part should be 'FOO\\n' but was '%v'", parts.results["FOO"])
+	}
+	if parts.results["BAR"] != "BAR" {
+		t.Errorf("BAR part should be 'BAR' but was '%v'", parts.results["BAR"])
+	}
+	if input, found := parts.inputs["in"]; !found || input != "" {
+		t.Errorf("in part should be empty but was '%v'", input)
+	}
+	if parts.section("BAR", false).line != 9 {
+		t.Errorf("BAR part should start in line 9 but starts in %v", parts.section("BAR", false).line)
+	}
+	if parts.String() != content {
+		t.Errorf("Parts should restore the content but got %q", parts.String())
+	}
+
+	_, err = splitTxtarParts("-- FOO --\n-- FOO --\n")
+	if err == nil || err.Error() != "2: result part FOO is already defined in line 1" {
+		t.Errorf("Duplicate parts should fail but got %v", err)
+	}
+}
+
+func TestMixedFormats(t *testing.T) {
+	RunMapTests(t, "testcases/mixed", func(source, operation string, t *testing.T) string {
+		switch operation {
+		case "upper":
+			return strings.ToUpper(source)
+		case "lower":
+			return strings.ToLower(source)
+		}
+		t.Fatalf("Wrong operation %v", operation)
+		return ""
+	})
+}
+
+func TestUpdateTxtar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	file := filepath.Join(dir, "case.txtar")
+	if err := ioutil.WriteFile(file, []byte("main\n-- upper --\nwrong\n-- lower --\nmain\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	*updateFlag = true
+	defer func() { *updateFlag = false }()
+
+	RunMapTest(t, file, func(source, operation string, t *testing.T) string {
+		if operation == "upper" {
+			return strings.ToUpper(source) + "\n#####"
+		}
+		return source
+	})
+
+	bytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "main\n-- upper --\nMAIN\n#####\n-- lower --\nmain\n"
+	if string(bytes) != expected {
+		t.Errorf("Updated file should be\n%v\nbut is\n%v", expected, string(bytes))
+	}
+}
@@ -0,0 +1,427 @@
+package treedata
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/creichlin/gutil"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// PathError is the error for a single value in the tree.
+// Path is a dotted path like servers[2].port, it is empty
+// for the root of the tree.
+type PathError struct {
+	Path string
+	Err  error
+}
+
+func (pe *PathError) Error() string {
+	if pe.Path == "" {
+		return pe.Err.Error()
+	}
+	return pe.Path + ": " + pe.Err.Error()
+}
+
+// Decode takes a tree of maps, lists and simple values like the ones
+// created by SanitizeForJSON and writes it into the value target points to.
+// Struct fields are matched by their json or yaml tag, or case insensitive
+// by the field name if there is no tag. Numbers are converted to the
+// int, uint or float kind of the target, values that do not fit are errors.
+// time.Duration fields can be set by strings like "1m30s".
+// Decoding continues after an error, all errors are returned
+// together as *gutil.ErrorCollector holding *PathError values.
+func Decode(in interface{}, target interface{}) error {
+	return decodeRoot(in, target, false)
+}
+
+// DecodeStrict works like Decode, except that map keys which have
+// no matching struct field are reported as errors.
+func DecodeStrict(in interface{}, target interface{}) error {
+	return decodeRoot(in, target, true)
+}
+
+type decoder struct {
+	strict bool
+	errors *gutil.ErrorCollector
+}
+
+func decodeRoot(in interface{}, target interface{}, strict bool) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("decode target must be a non nil pointer but is %T", target)
+	}
+
+	d := &decoder{
+		strict: strict,
+		errors: gutil.NewErrorCollector(),
+	}
+	d.decode("", in, value.Elem())
+	return d.errors.ThisOrNil()
+}
+
+func (d *decoder) fail(path string, format string, values ...interface{}) {
+	d.errors.Add(&PathError{Path: path, Err: fmt.Errorf(format, values...)})
+}
+
+func (d *decoder) decode(path string, in interface{}, out reflect.Value) {
+	if in == nil {
+		switch out.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			out.Set(reflect.Zero(out.Type()))
+		}
+		return
+	}
+
+	if out.Type() == durationType {
+		d.decodeDuration(path, in, out)
+		return
+	}
+
+	inValue := reflect.ValueOf(in)
+
+	switch out.Kind() {
+	case reflect.Interface:
+		if !inValue.Type().AssignableTo(out.Type()) {
+			d.fail(path, "cannot assign %T to %v", in, out.Type())
+			return
+		}
+		out.Set(inValue)
+
+	case reflect.Ptr:
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+		d.decode(path, in, out.Elem())
+
+	case reflect.Struct:
+		d.decodeStruct(path, inValue, out)
+
+	case reflect.Map:
+		d.decodeMap(path, inValue, out)
+
+	case reflect.Slice:
+		if inValue.Kind() != reflect.Slice {
+			d.fail(path, "expected a list but got %T", in)
+			return
+		}
+		slice := reflect.MakeSlice(out.Type(), inValue.Len(), inValue.Len())
+		for i := 0; i < inValue.Len(); i++ {
+			d.decode(fmt.Sprintf("%v[%v]", path, i), inValue.Index(i).Interface(), slice.Index(i))
+		}
+		out.Set(slice)
+
+	case reflect.Array:
+		if inValue.Kind() != reflect.Slice {
+			d.fail(path, "expected a list but got %T", in)
+			return
+		}
+		if inValue.Len() != out.Len() {
+			d.fail(path, "expected a list of %v elements but got %v", out.Len(), inValue.Len())
+			return
+		}
+		for i := 0; i < inValue.Len(); i++ {
+			d.decode(fmt.Sprintf("%v[%v]", path, i), inValue.Index(i).Interface(), out.Index(i))
+		}
+
+	case reflect.String:
+		if inValue.Kind() != reflect.String {
+			d.fail(path, "expected a string but got %T", in)
+			return
+		}
+		out.SetString(inValue.String())
+
+	case reflect.Bool:
+		if inValue.Kind() != reflect.Bool {
+			d.fail(path, "expected a bool but got %T", in)
+			return
+		}
+		out.SetBool(inValue.Bool())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		d.decodeInt(path, inValue, out)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		d.decodeUint(path, inValue, out)
+
+	case reflect.Float32, reflect.Float64:
+		d.decodeFloat(path, inValue, out)
+
+	default:
+		d.fail(path, "unsupported target type %v", out.Type())
+	}
+}
+
+func (d *decoder) decodeDuration(path string, in interface{}, out reflect.Value) {
+	if str, isString := in.(string); isString {
+		duration, err := time.ParseDuration(str)
+		if err != nil {
+			d.errors.Add(&PathError{Path: path, Err: err})
+			return
+		}
+		out.SetInt(int64(duration))
+		return
+	}
+	d.decodeInt(path, reflect.ValueOf(in), out)
+}
+
+func (d *decoder) decodeInt(path string, in reflect.Value, out reflect.Value) {
+	var value int64
+	switch in.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = in.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if in.Uint() > math.MaxInt64 {
+			d.fail(path, "%v overflows %v", in.Uint(), out.Type())
+			return
+		}
+		value = int64(in.Uint())
+	case reflect.Float32, reflect.Float64:
+		f := in.Float()
+		if f != math.Trunc(f) {
+			d.fail(path, "%v is not an integer", f)
+			return
+		}
+		if f < math.MinInt64 || f >= math.MaxInt64 {
+			d.fail(path, "%v overflows %v", f, out.Type())
+			return
+		}
+		value = int64(f)
+	default:
+		d.fail(path, "expected a number but got %v", in.Type())
+		return
+	}
+
+	if out.OverflowInt(value) {
+		d.fail(path, "%v overflows %v", value, out.Type())
+		return
+	}
+	out.SetInt(value)
+}
+
+func (d *decoder) decodeUint(path string, in reflect.Value, out reflect.Value) {
+	var value uint64
+	switch in.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if in.Int() < 0 {
+			d.fail(path, "%v overflows %v", in.Int(), out.Type())
+			return
+		}
+		value = uint64(in.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = in.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := in.Float()
+		if f != math.Trunc(f) {
+			d.fail(path, "%v is not an integer", f)
+			return
+		}
+		if f < 0 || f >= math.MaxUint64 {
+			d.fail(path, "%v overflows %v", f, out.Type())
+			return
+		}
+		value = uint64(f)
+	default:
+		d.fail(path, "expected a number but got %v", in.Type())
+		return
+	}
+
+	if out.OverflowUint(value) {
+		d.fail(path, "%v overflows %v", value, out.Type())
+		return
+	}
+	out.SetUint(value)
+}
+
+func (d *decoder) decodeFloat(path string, in reflect.Value, out reflect.Value) {
+	var value float64
+	switch in.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = float64(in.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = float64(in.Uint())
+	case reflect.Float32, reflect.Float64:
+		value = in.Float()
+	default:
+		d.fail(path, "expected a number but got %v", in.Type())
+		return
+	}
+
+	if out.OverflowFloat(value) {
+		d.fail(path, "%v overflows %v", value, out.Type())
+		return
+	}
+	out.SetFloat(value)
+}
+
+func (d *decoder) decodeMap(path string, in reflect.Value, out reflect.Value) {
+	if in.Kind() != reflect.Map {
+		d.fail(path, "expected a map but got %v", in.Type())
+		return
+	}
+	keyType := out.Type().Key()
+	if keyType.Kind() != reflect.String {
+		d.fail(path, "unsupported map key type %v", keyType)
+		return
+	}
+
+	if out.IsNil() {
+		out.Set(reflect.MakeMap(out.Type()))
+	}
+
+	for _, key := range d.sortedKeys(path, in) {
+		elem := reflect.New(out.Type().Elem()).Elem()
+		d.decode(joinPath(path, key.name), in.MapIndex(key.value).Interface(), elem)
+		out.SetMapIndex(reflect.ValueOf(key.name).Convert(keyType), elem)
+	}
+}
+
+func (d *decoder) decodeStruct(path string, in reflect.Value, out reflect.Value) {
+	if in.Kind() != reflect.Map {
+		d.fail(path, "expected a map but got %v", in.Type())
+		return
+	}
+
+	fields := structFields(out.Type())
+
+	for _, key := range d.sortedKeys(path, in) {
+		index, found := fields[key.name]
+		if !found {
+			index, found = fields[strings.ToLower(key.name)]
+		}
+		if !found {
+			if d.strict {
+				d.errors.Add(&PathError{Path: joinPath(path, key.name), Err: errors.New("unknown key")})
+			}
+			continue
+		}
+
+		field, err := fieldByIndex(out, index)
+		if err != nil {
+			d.errors.Add(&PathError{Path: joinPath(path, key.name), Err: err})
+			continue
+		}
+		d.decode(joinPath(path, key.name), in.MapIndex(key.value).Interface(), field)
+	}
+}
+
+// mapKey is a key of the input map with it's name as string
+type mapKey struct {
+	name  string
+	value reflect.Value
+}
+
+// sortedKeys returns the keys of a map sorted by name. Keys that are
+// not strings are converted with fmt.Sprint like Load does it. If that
+// makes keys the same, like 1 and "1", an error is added and they are
+// skipped.
+func (d *decoder) sortedKeys(path string, in reflect.Value) []mapKey {
+	keys := []mapKey{}
+	counts := map[string]int{}
+	for _, value := range in.MapKeys() {
+		name := fmt.Sprint(value.Interface())
+		keys = append(keys, mapKey{name: name, value: value})
+		counts[name]++
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].name < keys[j].name })
+
+	unique := []mapKey{}
+	for i, key := range keys {
+		if counts[key.name] == 1 {
+			unique = append(unique, key)
+		} else if i == 0 || keys[i-1].name != key.name {
+			d.fail(joinPath(path, key.name), "key is defined %v times", counts[key.name])
+		}
+	}
+	return unique
+}
+
+// structFields returns a map of names to field indexes. Names from tags
+// are used as is, field names without a tag are lowercased. Embedded
+// structs without a tag are flattened.
+func structFields(structType reflect.Type) map[string][]int {
+	fields := map[string][]int{}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue // unexported
+		}
+
+		name := tagName(field)
+		if name == "-" {
+			continue
+		}
+
+		if name == "" && field.Anonymous {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for subName, subIndex := range structFields(embedded) {
+					if _, exists := fields[subName]; !exists {
+						fields[subName] = append([]int{i}, subIndex...)
+					}
+				}
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = []int{i}
+	}
+
+	return fields
+}
+
+func tagName(field reflect.StructField) string {
+	for _, tagKey := range []string{"json", "yaml"} {
+		tag, found := field.Tag.Lookup(tagKey)
+		if !found {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// fieldByIndex works like reflect.Value.FieldByIndex but allocates
+// nil pointers to embedded structs on the way
+func fieldByIndex(value reflect.Value, index []int) (reflect.Value, error) {
+	for i, fieldIndex := range index {
+		if i > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				if !value.CanSet() {
+					return value, fmt.Errorf("cannot set embedded pointer to unexported %v", value.Type().Elem())
+				}
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.Field(fieldIndex)
+	}
+	return value, nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
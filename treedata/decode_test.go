@@ -0,0 +1,130 @@
+package treedata
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/creichlin/gutil"
+)
+
+type server struct {
+	Host    string        `json:"host"`
+	Port    uint16        `yaml:"port"`
+	Timeout time.Duration `json:"timeout"`
+	Weight  float32
+	Tags    []string `json:"tags"`
+}
+
+type config struct {
+	Name    string             `json:"name"`
+	Servers []server           `json:"servers"`
+	Limits  map[string]int     `json:"limits"`
+	Backup  *server            `json:"backup"`
+	Extra   interface{}        `json:"extra"`
+	Ignored string             `json:"-"`
+	Named   map[string]*server `json:"named"`
+}
+
+func TestDecode(t *testing.T) {
+	tree := SanitizeForJSON(map[string]interface{}{
+		"name": "demo",
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a", "port": 80, "timeout": "1m30s", "Weight": 0.5},
+			map[string]interface{}{"host": "b", "port": 8080.0, "tags": []interface{}{"x", "y"}},
+		},
+		"limits": map[interface{}]interface{}{"cpu": 4.0},
+		"backup": map[string]interface{}{"host": "c"},
+		"extra":  []interface{}{1, "two"},
+		"named":  map[string]interface{}{"n": map[string]interface{}{"port": 1}},
+	})
+
+	c := &config{}
+	err := Decode(tree, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Name != "demo" {
+		t.Errorf("name should be demo but is %v", c.Name)
+	}
+	if len(c.Servers) != 2 || c.Servers[0].Port != 80 || c.Servers[1].Port != 8080 {
+		t.Errorf("servers not decoded correctly, %v", c.Servers)
+	}
+	if c.Servers[0].Timeout != 90*time.Second {
+		t.Errorf("timeout should be 1m30s but is %v", c.Servers[0].Timeout)
+	}
+	if c.Servers[0].Weight != 0.5 {
+		t.Errorf("weight should be 0.5 but is %v", c.Servers[0].Weight)
+	}
+	if len(c.Servers[1].Tags) != 2 || c.Servers[1].Tags[1] != "y" {
+		t.Errorf("tags not decoded correctly, %v", c.Servers[1].Tags)
+	}
+	if c.Limits["cpu"] != 4 {
+		t.Errorf("cpu limit should be 4 but is %v", c.Limits["cpu"])
+	}
+	if c.Backup == nil || c.Backup.Host != "c" {
+		t.Errorf("backup not decoded correctly, %v", c.Backup)
+	}
+	if extra, isList := c.Extra.([]interface{}); !isList || len(extra) != 2 {
+		t.Errorf("extra should be a list but is %v", c.Extra)
+	}
+	if c.Named["n"] == nil || c.Named["n"].Port != 1 {
+		t.Errorf("named not decoded correctly, %v", c.Named)
+	}
+}
+
+func TestDecodeCollectsErrors(t *testing.T) {
+	tree := map[string]interface{}{
+		"name": 5,
+		"servers": []interface{}{
+			map[string]interface{}{"port": 70000, "timeout": "soon"},
+			map[string]interface{}{"port": 1.5, "unknown": true},
+		},
+	}
+
+	err := DecodeStrict(tree, &config{})
+	if err == nil {
+		t.Fatal("decode should fail")
+	}
+
+	expected := []string{
+		"name: expected a string but got int",
+		"servers[0].port: 70000 overflows uint16",
+		`servers[0].timeout: time: invalid duration "soon"`,
+		"servers[1].port: 1.5 is not an integer",
+		"servers[1].unknown: unknown key",
+	}
+	actual := err.(*gutil.ErrorCollector).StringList()
+	if len(actual) != len(expected) {
+		t.Fatalf("expected errors\n%v\nbut got\n%v", expected, err)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("error %v should be '%v' but is '%v'", i, expected[i], actual[i])
+		}
+	}
+}
+
+func TestDecodeNonStringKeys(t *testing.T) {
+	target := map[string]int{}
+	err := Decode(map[interface{}]interface{}{1: 2, "a": 3}, &target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(target, map[string]int{"1": 2, "a": 3}) {
+		t.Errorf("keys should be converted to strings but got %v", target)
+	}
+
+	err = Decode(map[interface{}]interface{}{1: 2, "1": 3, "a": 4}, &target)
+	if err == nil || err.Error() != "1: key is defined 2 times" {
+		t.Errorf("duplicate keys should fail with '1: key is defined 2 times' but got %v", err)
+	}
+}
+
+func TestDecodeIgnoresUnknownKeys(t *testing.T) {
+	err := Decode(map[string]interface{}{"foo": 1}, &config{})
+	if err != nil {
+		t.Errorf("unknown keys should be ignored but got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package treedata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gio "github.com/creichlin/gutil/io"
+	"github.com/creichlin/gutil/treedata/sanitize"
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a serialization format for trees
+type Format string
+
+// Supported formats
+const (
+	JSON Format = "json"
+	YAML Format = "yaml"
+	TOML Format = "toml"
+	Env  Format = "env"
+)
+
+var extensions = map[string]Format{
+	".json": JSON,
+	".yaml": YAML,
+	".yml":  YAML,
+	".toml": TOML,
+	".env":  Env,
+}
+
+// FormatOf returns the format of a file by it's extension.
+// Files named .env or ending with .env are dotenv files.
+func FormatOf(path string) (Format, error) {
+	format, found := extensions[strings.ToLower(filepath.Ext(path))]
+	if !found {
+		return "", fmt.Errorf("cannot detect format of %v, unknown extension", path)
+	}
+	return format, nil
+}
+
+// Load reads the file at path, parses it with the format
+// matching it's extension and returns it as a sanitized tree
+func Load(path string) (interface{}, error) {
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := Unmarshal(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %v, %v", path, err)
+	}
+	return tree, nil
+}
+
+// Save writes the tree to path in the format matching it's extension.
+// The file is written atomically, if it exists it keeps it's permissions.
+func Save(path string, tree interface{}) error {
+	format, err := FormatOf(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := Marshal(tree, format)
+	if err != nil {
+		return fmt.Errorf("failed to save %v, %v", path, err)
+	}
+
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	return gio.WriteFileAtomic(path, data, perm)
+}
+
+// Unmarshal parses data in the given format and returns
+// it as a sanitized tree
+func Unmarshal(data []byte, format Format) (interface{}, error) {
+	var tree interface{}
+
+	switch format {
+	case JSON:
+		if err := json.Unmarshal(data, &tree); err != nil {
+			return nil, err
+		}
+
+	case YAML:
+		if err := yaml.Unmarshal(data, &tree); err != nil {
+			return nil, err
+		}
+
+	case TOML:
+		tomlTree, err := toml.LoadBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		tree = tomlTree.ToMap()
+
+	case Env:
+		env, err := godotenv.UnmarshalBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		envTree := map[string]interface{}{}
+		for key, value := range env {
+			envTree[key] = value
+		}
+		tree = envTree
+
+	default:
+		return nil, fmt.Errorf("unsupported format %v", format)
+	}
+
+	tree, err := sanitize.Normalize(tree)
+	if err != nil {
+		return nil, err
+	}
+	return SanitizeForJSON(tree), nil
+}
+
+// Marshal serializes the tree in the given format
+func Marshal(tree interface{}, format Format) ([]byte, error) {
+	tree, err := sanitize.Normalize(tree)
+	if err != nil {
+		return nil, err
+	}
+	tree = SanitizeForJSON(tree)
+
+	switch format {
+	case JSON:
+		data, err := json.MarshalIndent(tree, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+
+	case YAML:
+		buffer := &bytes.Buffer{}
+		encoder := yaml.NewEncoder(buffer)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(tree); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), encoder.Close()
+
+	case TOML:
+		root, isMap := tree.(map[string]interface{})
+		if !isMap {
+			return nil, fmt.Errorf("toml root must be a map but is %T", tree)
+		}
+		tomlTree, err := toml.TreeFromMap(root)
+		if err != nil {
+			return nil, err
+		}
+		str, err := tomlTree.ToTomlString()
+		return []byte(str), err
+
+	case Env:
+		return marshalEnv(tree)
+
+	default:
+		return nil, fmt.Errorf("unsupported format %v", format)
+	}
+}
+
+func marshalEnv(tree interface{}) ([]byte, error) {
+	root, isMap := tree.(map[string]interface{})
+	if !isMap {
+		return nil, fmt.Errorf("env root must be a map but is %T", tree)
+	}
+
+	keys := []string{}
+	for key := range root {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := map[string]string{}
+	for _, key := range keys {
+		switch value := root[key].(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("env value of %v must be a simple value but is %T", key, value)
+		case nil:
+			env[key] = ""
+		default:
+			env[key] = fmt.Sprint(value)
+		}
+	}
+
+	str, err := godotenv.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(str + "\n"), nil
+}
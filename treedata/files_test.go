@@ -0,0 +1,74 @@
+package treedata
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "treedata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	nested := map[string]interface{}{
+		"name":    "demo",
+		"enabled": true,
+		"port":    8080,
+		"ratio":   0.5,
+		"server": map[string]interface{}{
+			"hosts": []interface{}{"a", "b"},
+		},
+	}
+	flat := map[string]interface{}{
+		"NAME": "demo",
+		"PORT": "8080",
+	}
+
+	for _, file := range []string{"c.json", "c.yaml", "c.yml", "c.toml", ".env", "c.env"} {
+		file := file
+		t.Run(file, func(t *testing.T) {
+			tree := nested
+			format, _ := FormatOf(file)
+			if format == Env {
+				tree = flat
+			}
+
+			path := filepath.Join(dir, file)
+			if err := Save(path, tree); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// json has no integers
+			if format == JSON {
+				loaded.(map[string]interface{})["port"] = int(loaded.(map[string]interface{})["port"].(float64))
+			}
+
+			if !reflect.DeepEqual(loaded, tree) {
+				t.Errorf("loaded tree differs from saved tree\nexpected: %#v\nactual:   %#v", tree, loaded)
+			}
+		})
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := Load("config.ini"); err == nil {
+		t.Errorf("loading an unknown format should fail")
+	}
+}
+
+func TestSaveEnvRejectsNesting(t *testing.T) {
+	_, err := Marshal(map[string]interface{}{"a": []interface{}{1}}, Env)
+	if err == nil {
+		t.Errorf("env cannot contain lists")
+	}
+}
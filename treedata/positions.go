@@ -0,0 +1,282 @@
+package treedata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"unicode/utf8"
+
+	"github.com/creichlin/gutil"
+	"github.com/creichlin/gutil/treedata/sanitize"
+	"gopkg.in/yaml.v3"
+)
+
+// Position is a location in a source file.
+// Line and Column start at 1.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%v:%v:%v", p.File, p.Line, p.Column)
+}
+
+// Positions maps paths of a tree, as they are used in PathError,
+// to the location where the value is defined. For map entries
+// it's the position of the key.
+type Positions map[string]Position
+
+// PositionError is an error with the source position
+// it belongs to
+type PositionError struct {
+	Position Position
+	Err      error
+}
+
+func (pe *PositionError) Error() string {
+	return pe.Position.String() + ": " + pe.Err.Error()
+}
+
+// LoadWithPositions works like Load but also returns the position
+// of each value in the file. Only JSON and YAML files are supported.
+func LoadWithPositions(path string) (interface{}, Positions, error) {
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tree interface{}
+	var positions Positions
+	switch format {
+	case JSON:
+		tree, err = Unmarshal(data, format)
+		if err == nil {
+			positions, err = jsonPositions(path, data)
+		}
+	case YAML:
+		tree, positions, err = yamlWithPositions(path, data)
+	default:
+		err = fmt.Errorf("positions are not supported for format %v", format)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load %v, %v", path, err)
+	}
+
+	return tree, positions, nil
+}
+
+// Lookup returns the position of the given path. If there is no
+// position for it, the position of the nearest parent is returned.
+// This way errors for missing keys point to the enclosing map.
+func (ps Positions) Lookup(path string) (Position, bool) {
+	for {
+		if pos, found := ps[path]; found {
+			return pos, true
+		}
+		if path == "" {
+			return Position{}, false
+		}
+		path = parentPath(path)
+	}
+}
+
+// Annotate adds source positions to errors. *PathError values are
+// wrapped in a *PositionError, if err is a *gutil.ErrorCollector
+// a new collector with all it's errors annotated is returned.
+// Other errors are returned unchanged.
+func (ps Positions) Annotate(err error) error {
+	switch t := err.(type) {
+	case *gutil.ErrorCollector:
+		annotated := gutil.NewErrorCollector()
+		for _, e := range t.All() {
+			annotated.Add(ps.Annotate(e))
+		}
+		return annotated
+
+	case *PathError:
+		if pos, found := ps.Lookup(t.Path); found {
+			return &PositionError{Position: pos, Err: t}
+		}
+	}
+	return err
+}
+
+func parentPath(path string) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] == '.' || path[i] == '[' {
+			return path[:i]
+		}
+	}
+	return ""
+}
+
+// yamlWithPositions decodes the values and their positions
+// from the same node tree, so they always agree
+func yamlWithPositions(file string, data []byte) (interface{}, Positions, error) {
+	root := &yaml.Node{}
+	if err := yaml.Unmarshal(data, root); err != nil {
+		return nil, nil, err
+	}
+
+	var tree interface{}
+	if len(root.Content) > 0 {
+		if err := root.Decode(&tree); err != nil {
+			return nil, nil, err
+		}
+	}
+	tree, err := sanitize.Normalize(tree)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	positions := Positions{}
+	var walk func(node *yaml.Node, path string)
+	walk = func(node *yaml.Node, path string) {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, child := range node.Content {
+				walk(child, path)
+			}
+
+		case yaml.AliasNode:
+			walk(node.Alias, path)
+
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Tag != "!!merge" {
+					continue
+				}
+				// merged keys are defined where the anchor is, the
+				// first merged map and the keys of the mapping itself
+				// take precedence, so they are walked afterwards
+				merge := node.Content[i+1]
+				if merge.Kind == yaml.SequenceNode {
+					for j := len(merge.Content) - 1; j >= 0; j-- {
+						walk(merge.Content[j], path)
+					}
+				} else {
+					walk(merge, path)
+				}
+			}
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				if key.Tag == "!!merge" {
+					continue
+				}
+				keyPath := joinPath(path, key.Value)
+				positions[keyPath] = Position{File: file, Line: key.Line, Column: key.Column}
+				walk(value, keyPath)
+			}
+
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				childPath := fmt.Sprintf("%v[%v]", path, i)
+				positions[childPath] = Position{File: file, Line: child.Line, Column: child.Column}
+				walk(child, childPath)
+			}
+		}
+	}
+
+	if len(root.Content) > 0 {
+		positions[""] = Position{File: file, Line: root.Content[0].Line, Column: root.Content[0].Column}
+	}
+	walk(root, "")
+
+	return SanitizeForJSON(tree), positions, nil
+}
+
+// jsonScanner uses the tokens of a json.Decoder to find the positions
+// of values. The decoder only reports the offset after a token so the
+// start of the next token is found by skipping separators.
+type jsonScanner struct {
+	file      string
+	data      []byte
+	decoder   *json.Decoder
+	positions Positions
+	// position at offset, tokens are read in order so
+	// it only moves forward
+	offset, line, column int
+}
+
+func jsonPositions(file string, data []byte) (Positions, error) {
+	scanner := &jsonScanner{
+		file:      file,
+		data:      data,
+		decoder:   json.NewDecoder(bytes.NewReader(data)),
+		positions: Positions{},
+		line:      1,
+		column:    1,
+	}
+
+	scanner.positions[""] = scanner.next()
+	if err := scanner.value(""); err != nil {
+		return nil, err
+	}
+	return scanner.positions, nil
+}
+
+func (js *jsonScanner) value(path string) error {
+	token, err := js.decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		for js.decoder.More() {
+			pos := js.next()
+			key, err := js.decoder.Token()
+			if err != nil {
+				return err
+			}
+			keyPath := joinPath(path, fmt.Sprint(key))
+			js.positions[keyPath] = pos
+			if err := js.value(keyPath); err != nil {
+				return err
+			}
+		}
+		_, err = js.decoder.Token() // closing }
+		return err
+
+	case json.Delim('['):
+		for index := 0; js.decoder.More(); index++ {
+			indexPath := fmt.Sprintf("%v[%v]", path, index)
+			js.positions[indexPath] = js.next()
+			if err := js.value(indexPath); err != nil {
+				return err
+			}
+		}
+		_, err = js.decoder.Token() // closing ]
+		return err
+	}
+
+	return nil
+}
+
+// next returns the position of the next token
+func (js *jsonScanner) next() Position {
+	offset := int(js.decoder.InputOffset())
+	for offset < len(js.data) && bytes.IndexByte([]byte(" \t\r\n,:"), js.data[offset]) != -1 {
+		offset++
+	}
+
+	for js.offset < offset {
+		char, size := utf8.DecodeRune(js.data[js.offset:])
+		if char == '\n' {
+			js.line++
+			js.column = 1
+		} else {
+			js.column++
+		}
+		js.offset += size
+	}
+	return Position{File: js.file, Line: js.line, Column: js.column}
+}
@@ -0,0 +1,153 @@
+package treedata
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type portConfig struct {
+	Server struct {
+		Port uint16 `yaml:"port"`
+	} `yaml:"server"`
+	Hosts []string `yaml:"hosts"`
+}
+
+func writeTemp(t *testing.T, name, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "treedata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) } // nolint: errcheck
+}
+
+func TestYAMLPositions(t *testing.T) {
+	path, cleanup := writeTemp(t, "config.yaml", `
+server:
+  port: 70000
+hosts:
+  - a
+  - 5
+`)
+	defer cleanup()
+
+	tree, positions, err := LoadWithPositions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"server":      path + ":2:1",
+		"server.port": path + ":3:3",
+		"hosts[1]":    path + ":6:5",
+	}
+	for key, pos := range expected {
+		if positions[key].String() != pos {
+			t.Errorf("position of %v should be %v but is %v", key, pos, positions[key])
+		}
+	}
+
+	err = positions.Annotate(Decode(tree, &portConfig{}))
+	if err == nil {
+		t.Fatal("decode should fail")
+	}
+	expectedErr := path + ":6:5: hosts[1]: expected a string but got int\n" +
+		path + ":3:3: server.port: 70000 overflows uint16"
+	if err.Error() != expectedErr {
+		t.Errorf("error should be\n%v\nbut is\n%v", expectedErr, err)
+	}
+}
+
+func TestJSONPositions(t *testing.T) {
+	path, cleanup := writeTemp(t, "config.json", `{
+  "server": {"port": 1},
+  "hosts": [
+    "a",
+      "ü", {"x": []}
+  ]
+}`)
+	defer cleanup()
+
+	_, positions, err := LoadWithPositions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"":            path + ":1:1",
+		"server":      path + ":2:3",
+		"server.port": path + ":2:14",
+		"hosts[0]":    path + ":4:5",
+		"hosts[1]":    path + ":5:7",
+		"hosts[2]":    path + ":5:12",
+		"hosts[2].x":  path + ":5:13",
+	}
+	for key, pos := range expected {
+		if positions[key].String() != pos {
+			t.Errorf("position of %v should be %v but is %v", key, pos, positions[key])
+		}
+	}
+
+	pos, _ := positions.Lookup("server.missing")
+	if pos.String() != path+":2:3" {
+		t.Errorf("missing keys should resolve to their parent but was %v", pos)
+	}
+}
+
+func TestYAMLPositionsMatchValues(t *testing.T) {
+	path, cleanup := writeTemp(t, "config.yaml", `
+base: &base
+  port: 1
+on: yes
+server:
+  <<: *base
+  host: x
+client:
+  port: 2
+  <<: *base
+`)
+	defer cleanup()
+
+	tree, positions, err := LoadWithPositions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tree, loaded) {
+		t.Errorf("tree with positions should be the same as loaded\nexpected: %#v\nactual:   %#v", loaded, tree)
+	}
+
+	root := tree.(map[string]interface{})
+	if root["on"] != "yes" {
+		t.Errorf("on should be the string yes but is %v", root["on"])
+	}
+	if positions["on"].String() != path+":4:1" {
+		t.Errorf("position of on should be %v:4:1 but is %v", path, positions["on"])
+	}
+
+	server := root["server"].(map[string]interface{})
+	if server["port"] != 1 {
+		t.Errorf("merged port should be 1 but is %v", server["port"])
+	}
+	if positions["server.port"].String() != path+":3:3" {
+		t.Errorf("position of merged port should be %v:3:3 but is %v", path, positions["server.port"])
+	}
+
+	client := root["client"].(map[string]interface{})
+	if client["port"] != 2 {
+		t.Errorf("explicit port should be 2 but is %v", client["port"])
+	}
+	if positions["client.port"].String() != path+":9:3" {
+		t.Errorf("position of explicit port should be %v:9:3 but is %v", path, positions["client.port"])
+	}
+}
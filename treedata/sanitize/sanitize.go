@@ -0,0 +1,140 @@
+// Package sanitize converts the trees parsers produce into
+// trees of maps with string keys, slices and simple values.
+// It's used by treedata and kept free of dependencies, so
+// testin can compare trees the same way without importing
+// treedata, which would be an import cycle for the tests of
+// format.
+package sanitize
+
+import (
+	"fmt"
+	"time"
+)
+
+// ForJSON will take an interface and make a deep copy of
+// it, replacing map keys with string representations
+// this will allow the datastructure to be written as JSON
+func ForJSON(in interface{}) interface{} {
+	if in == nil {
+		return nil
+	}
+
+	switch t := in.(type) {
+	case string:
+		return t
+
+	case bool:
+		return t
+
+	case float64:
+		return t
+
+	case int:
+		return t
+
+	case []interface{}:
+		clone := make([]interface{}, 0)
+
+		for _, value := range t {
+			clone = append(clone, ForJSON(value))
+		}
+		return clone
+
+	case map[interface{}]interface{}:
+		clone := make(map[string]interface{})
+
+		for key, value := range t {
+			clone[key.(string)] = ForJSON(value)
+		}
+		return clone
+
+	case map[string]interface{}:
+		clone := make(map[string]interface{})
+
+		for key, value := range t {
+			clone[key] = ForJSON(value)
+		}
+		return clone
+
+	default:
+		panic(fmt.Sprintf("cannot sanityze %v %T, unsupported type", in, in))
+	}
+}
+
+// Normalize converts the values parsers produce that are not
+// supported by ForJSON. Instead of panicking it returns
+// an error for unknown types.
+func Normalize(in interface{}) (interface{}, error) {
+	switch t := in.(type) {
+	case nil, string, bool, float64, int:
+		return t, nil
+
+	case int8:
+		return int(t), nil
+	case int16:
+		return int(t), nil
+	case int32:
+		return int(t), nil
+	case int64:
+		if int64(int(t)) != t {
+			return float64(t), nil
+		}
+		return int(t), nil
+	case uint8:
+		return int(t), nil
+	case uint16:
+		return int(t), nil
+	case uint32:
+		return Normalize(int64(t))
+	case uint64:
+		if t > uint64(^uint(0)>>1) {
+			return float64(t), nil
+		}
+		return int(t), nil
+	case float32:
+		return float64(t), nil
+
+	case time.Time:
+		return t.Format(time.RFC3339Nano), nil
+
+	case fmt.Stringer:
+		// toml local dates and times
+		return t.String(), nil
+
+	case []interface{}:
+		clone := make([]interface{}, 0, len(t))
+		for _, value := range t {
+			nValue, err := Normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			clone = append(clone, nValue)
+		}
+		return clone, nil
+
+	case map[interface{}]interface{}:
+		clone := make(map[string]interface{})
+		for key, value := range t {
+			nValue, err := Normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			clone[fmt.Sprint(key)] = nValue
+		}
+		return clone, nil
+
+	case map[string]interface{}:
+		clone := make(map[string]interface{})
+		for key, value := range t {
+			nValue, err := Normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			clone[key] = nValue
+		}
+		return clone, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported value %v of type %T", in, in)
+	}
+}